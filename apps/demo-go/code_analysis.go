@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/parallax/sdk-go/pkg/parallax"
+)
+
+// confidenceAggregator is stateless, so summarizeReport shares one
+// instance instead of allocating per call.
+var confidenceAggregator = &parallax.ConfidenceAggregator{}
+
+// CodeReport is the structured result of analyzing a Go source file with
+// go/parser + go/ast, replacing analyzeCode's old flat keyword-match map
+// with real per-function findings.
+type CodeReport struct {
+	Functions            []FunctionReport `json:"functions"`
+	CommentGroups        int              `json:"commentGroups"`
+	UndocumentedExported []string         `json:"undocumentedExported"`
+}
+
+// FunctionReport is one function or method declaration's findings.
+type FunctionReport struct {
+	Name                 string `json:"name"`
+	IsTest               bool   `json:"isTest"`
+	IsBenchmark          bool   `json:"isBenchmark"`
+	IsFuzz               bool   `json:"isFuzz"`
+	HasErrorHandling     bool   `json:"hasErrorHandling"`
+	CyclomaticComplexity int    `json:"cyclomaticComplexity"`
+	Exported             bool   `json:"exported"`
+	Documented           bool   `json:"documented"`
+}
+
+// analyzeGoSource parses src as a single Go source file and builds a
+// CodeReport from its AST.
+func analyzeGoSource(src string) (*CodeReport, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parse code: %w", err)
+	}
+
+	report := &CodeReport{CommentGroups: len(file.Comments)}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		fr := FunctionReport{
+			Name:                 fn.Name.Name,
+			IsTest:               isTestFunc(fn),
+			IsBenchmark:          strings.HasPrefix(fn.Name.Name, "Benchmark"),
+			IsFuzz:               strings.HasPrefix(fn.Name.Name, "Fuzz"),
+			HasErrorHandling:     hasErrorHandling(fn),
+			CyclomaticComplexity: cyclomaticComplexity(fn),
+			Exported:             fn.Name.IsExported(),
+			Documented:           fn.Doc != nil && len(fn.Doc.List) > 0,
+		}
+		report.Functions = append(report.Functions, fr)
+
+		if fr.Exported && !fr.Documented {
+			report.UndocumentedExported = append(report.UndocumentedExported, fr.Name)
+		}
+	}
+
+	return report, nil
+}
+
+// isTestFunc reports whether fn is a test function by AST shape: a
+// top-level, Test-prefixed declaration taking a single *testing.T
+// parameter, rather than a substring match on the source text.
+func isTestFunc(fn *ast.FuncDecl) bool {
+	if !strings.HasPrefix(fn.Name.Name, "Test") {
+		return false
+	}
+	if fn.Recv != nil {
+		return false
+	}
+	if fn.Type.Params == nil || len(fn.Type.Params.List) != 1 {
+		return false
+	}
+	return paramIsTestingT(fn.Type.Params.List[0])
+}
+
+// paramIsTestingT reports whether field's type is *testing.T.
+func paramIsTestingT(field *ast.Field) bool {
+	star, ok := field.Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkgIdent, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	return pkgIdent.Name == "testing" && sel.Sel.Name == "T"
+}
+
+// hasErrorHandling walks fn's body for "if err != nil" checks and return
+// statements that pass along an "err"-named value.
+func hasErrorHandling(fn *ast.FuncDecl) bool {
+	if fn.Body == nil {
+		return false
+	}
+
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch stmt := n.(type) {
+		case *ast.IfStmt:
+			if isErrNilCheck(stmt.Cond) {
+				found = true
+			}
+		case *ast.ReturnStmt:
+			for _, res := range stmt.Results {
+				if ident, ok := res.(*ast.Ident); ok && ident.Name == "err" {
+					found = true
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// isErrNilCheck reports whether cond is "err != nil" or "nil != err".
+func isErrNilCheck(cond ast.Expr) bool {
+	bin, ok := cond.(*ast.BinaryExpr)
+	if !ok || bin.Op != token.NEQ {
+		return false
+	}
+	return (isErrIdent(bin.X) && isNilIdent(bin.Y)) || (isErrIdent(bin.Y) && isNilIdent(bin.X))
+}
+
+func isErrIdent(e ast.Expr) bool {
+	ident, ok := e.(*ast.Ident)
+	return ok && ident.Name == "err"
+}
+
+func isNilIdent(e ast.Expr) bool {
+	ident, ok := e.(*ast.Ident)
+	return ok && ident.Name == "nil"
+}
+
+// cyclomaticComplexity computes McCabe complexity for fn: 1 plus one for
+// every branch (if, for, range, case, select comm clause, && and ||).
+func cyclomaticComplexity(fn *ast.FuncDecl) int {
+	if fn.Body == nil {
+		return 1
+	}
+
+	complexity := 1
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch stmt := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.CaseClause, *ast.CommClause:
+			complexity++
+		case *ast.BinaryExpr:
+			if stmt.Op == token.LAND || stmt.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
+// gradeFunction buckets fr into a coarse quality grade for
+// ConfidenceAggregator.FromConsistency to compare across functions.
+func gradeFunction(fr FunctionReport) string {
+	switch {
+	case fr.IsTest, fr.IsBenchmark, fr.IsFuzz:
+		return "test"
+	case fr.HasErrorHandling && fr.Documented:
+		return "complete"
+	case fr.HasErrorHandling || fr.Documented:
+		return "partial"
+	default:
+		return "bare"
+	}
+}
+
+// summarizeReport derives a confidence score from how consistently
+// CodeReport's functions look "done" (tested, documented, error-handled),
+// via ConfidenceAggregator.FromConsistency, instead of the old hand-tuned
+// 0.75/0.9/0.6 constants: a file whose functions agree on a quality grade
+// is judged more confidently than one where some are solid and others
+// aren't.
+func summarizeReport(report *CodeReport) (confidence float64, reasoning string, uncertainties []string) {
+	if len(report.Functions) == 0 {
+		return 0.5, "No function declarations found to analyze", []string{"Empty source or no function declarations"}
+	}
+
+	grades := make([]interface{}, len(report.Functions))
+	testedCount, documentedCount, errorHandledCount := 0, 0, 0
+	for i, fn := range report.Functions {
+		grades[i] = gradeFunction(fn)
+		if fn.IsTest {
+			testedCount++
+		}
+		if fn.Documented {
+			documentedCount++
+		}
+		if fn.HasErrorHandling {
+			errorHandledCount++
+		}
+	}
+
+	confidence = confidenceAggregator.FromConsistency(grades)
+	reasoning = fmt.Sprintf("Analyzed %d function(s) via go/ast: %d tested, %d documented, %d with error handling",
+		len(report.Functions), testedCount, documentedCount, errorHandledCount)
+
+	if len(report.UndocumentedExported) > 0 {
+		uncertainties = append(uncertainties, fmt.Sprintf("Exported but undocumented: %s", strings.Join(report.UndocumentedExported, ", ")))
+	}
+	if testedCount == 0 {
+		uncertainties = append(uncertainties, "No test functions detected")
+	}
+	if errorHandledCount < len(report.Functions) {
+		uncertainties = append(uncertainties, "Some functions have no detected error handling")
+	}
+
+	return confidence, reasoning, uncertainties
+}
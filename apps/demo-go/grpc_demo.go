@@ -71,65 +71,123 @@ func (d *DemoAgent) Analyze(ctx context.Context, task string, data interface{})
 }
 
 func (d *DemoAgent) analyzeCode(data interface{}) (*parallax.AnalyzeResult, error) {
-	// Extract code string
-	var code string
-	switch v := data.(type) {
-	case string:
-		code = v
-	case map[string]interface{}:
-		if c, ok := v["code"].(string); ok {
-			code = c
-		}
+	code, err := extractCode(data)
+	if err != nil {
+		return nil, err
 	}
 
-	if code == "" {
-		return nil, fmt.Errorf("no code provided for analysis")
+	report, err := analyzeGoSource(code)
+	if err != nil {
+		return nil, fmt.Errorf("analyze code: %w", err)
 	}
 
-	// Simple code analysis
-	hasTests := containsAny(code, []string{"func Test", "_test.go", "t.Run"})
-	hasComments := containsAny(code, []string{"//", "/*"})
-	hasError := containsAny(code, []string{"if err != nil", "return err"})
-
-	quality := "medium"
-	confidence := 0.75
-	if hasTests && hasComments && hasError {
-		quality = "high"
-		confidence = 0.9
-	} else if !hasTests && !hasComments {
-		quality = "low"
-		confidence = 0.6
-	}
-
-	suggestions := []string{}
-	if !hasTests {
-		suggestions = append(suggestions, "Add unit tests")
-	}
-	if !hasComments {
-		suggestions = append(suggestions, "Add code comments")
-	}
-	if !hasError {
-		suggestions = append(suggestions, "Add error handling")
-	}
+	confidence, reasoning, uncertainties := summarizeReport(report)
 
 	return &parallax.AnalyzeResult{
-		Value: map[string]interface{}{
-			"hasTests":    hasTests,
-			"hasComments": hasComments,
-			"hasErrors":   hasError,
-			"quality":     quality,
-			"suggestions": suggestions,
-		},
+		Value:         report,
 		Confidence:    confidence,
-		Reasoning:     fmt.Sprintf("Analyzed Go code with %d lines", len(code)),
-		Uncertainties: []string{"Simple keyword matching", "No AST analysis"},
+		Reasoning:     reasoning,
+		Uncertainties: uncertainties,
 		Metadata: map[string]string{
-			"analyzer": "keyword-based",
-			"version":  "1.0",
+			"analyzer": "go/ast",
+			"version":  "2.0",
 		},
 	}, nil
 }
 
+// AnalyzeStream implements the optional StreamingAnalyzer interface (see
+// GrpcAgent.StreamAnalyze): for code analysis it emits one partial
+// AnalyzeResult per function as go/ast discovers it, each with
+// confidence recomputed via ConfidenceAggregator.FromConsistency over the
+// grades seen so far, followed by a final result carrying the complete
+// CodeReport. Other tasks fall back to a single Analyze call.
+func (d *DemoAgent) AnalyzeStream(ctx context.Context, task string, data interface{}) (<-chan *parallax.AnalyzeResult, error) {
+	if task != "analyze-code" && task != "code" {
+		result, err := d.Analyze(ctx, task, data)
+		if err != nil {
+			return nil, err
+		}
+		ch := make(chan *parallax.AnalyzeResult, 1)
+		ch <- result
+		close(ch)
+		return ch, nil
+	}
+
+	code, err := extractCode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := analyzeGoSource(code)
+	if err != nil {
+		return nil, fmt.Errorf("analyze code: %w", err)
+	}
+
+	ch := make(chan *parallax.AnalyzeResult)
+	go func() {
+		defer close(ch)
+
+		aggregator := &parallax.ConfidenceAggregator{}
+		grades := make([]interface{}, 0, len(report.Functions))
+
+		for _, fn := range report.Functions {
+			grades = append(grades, gradeFunction(fn))
+
+			partialConfidence := 0.5
+			if len(grades) >= 2 {
+				partialConfidence = aggregator.FromConsistency(grades)
+			}
+
+			partial := &parallax.AnalyzeResult{
+				Value:      fn,
+				Confidence: partialConfidence,
+				Reasoning:  fmt.Sprintf("Partial: analyzed function %s", fn.Name),
+			}
+
+			select {
+			case ch <- partial:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		confidence, reasoning, uncertainties := summarizeReport(report)
+		final := &parallax.AnalyzeResult{
+			Value:         report,
+			Confidence:    confidence,
+			Reasoning:     reasoning,
+			Uncertainties: uncertainties,
+			Metadata: map[string]string{
+				"analyzer": "go/ast",
+				"version":  "2.0",
+			},
+		}
+
+		select {
+		case ch <- final:
+		case <-ctx.Done():
+		}
+	}()
+
+	return ch, nil
+}
+
+// extractCode pulls the Go source string out of data, the shape Analyze
+// and AnalyzeStream both accept for "analyze-code"/"code" tasks.
+func extractCode(data interface{}) (string, error) {
+	switch v := data.(type) {
+	case string:
+		if v != "" {
+			return v, nil
+		}
+	case map[string]interface{}:
+		if c, ok := v["code"].(string); ok && c != "" {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("no code provided for analysis")
+}
+
 func (d *DemoAgent) getSystemInfo() (*parallax.AnalyzeResult, error) {
 	return &parallax.AnalyzeResult{
 		Value: map[string]interface{}{
@@ -151,31 +209,6 @@ func (d *DemoAgent) CheckHealth(ctx context.Context) (*parallax.HealthStatus, er
 	}, nil
 }
 
-func containsAny(text string, keywords []string) bool {
-	for _, keyword := range keywords {
-		if len(text) > 0 && len(keyword) > 0 {
-			// Simple contains check
-			if text == keyword || contains(text, keyword) {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-func contains(text, substr string) bool {
-	return len(text) >= len(substr) && (text == substr || findSubstring(text, substr) >= 0)
-}
-
-func findSubstring(text, substr string) int {
-	for i := 0; i <= len(text)-len(substr); i++ {
-		if text[i:i+len(substr)] == substr {
-			return i
-		}
-	}
-	return -1
-}
-
 func runDemo() error {
 	fmt.Println("🚀 Parallax Go SDK Demo\n")
 
@@ -0,0 +1,137 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=pxc
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Agents",type=integer,JSONPath=`.status.totalAgents`
+// +kubebuilder:printcolumn:name="Available",type=integer,JSONPath=`.status.availableAgents`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ParallaxCluster is the Schema for the parallaxclusters API. It composes
+// the control-plane, scheduler, registry, and monitor components plus one
+// or more fleets of agents into a single installable/upgradable topology,
+// mirroring how build-farm operators like kubecc's BuildCluster describe
+// a whole cluster as one object instead of one ParallaxAgent at a time.
+type ParallaxCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ParallaxClusterSpec   `json:"spec,omitempty"`
+	Status ParallaxClusterStatus `json:"status,omitempty"`
+}
+
+// ParallaxClusterSpec defines the desired state of ParallaxCluster
+type ParallaxClusterSpec struct {
+	// Components describes the control-plane pieces the cluster runs.
+	Components ComponentsSpec `json:"components,omitempty"`
+
+	// AgentTemplates describes the agent fleets, keyed by Role, that the
+	// cluster reconciles into child ParallaxAgent objects.
+	AgentTemplates []AgentTemplateSpec `json:"agentTemplates,omitempty"`
+}
+
+// ComponentsSpec groups the control-plane components a ParallaxCluster
+// reconciles alongside its agent fleets.
+type ComponentsSpec struct {
+	// ControlPlane runs the gRPC control-plane API agents register
+	// against.
+	ControlPlane ComponentSpec `json:"controlPlane,omitempty"`
+
+	// Scheduler assigns work to agents by capability and confidence.
+	Scheduler ComponentSpec `json:"scheduler,omitempty"`
+
+	// Registry is the agent registry and lease store.
+	Registry ComponentSpec `json:"registry,omitempty"`
+
+	// Monitor scrapes and aggregates fleet-wide metrics.
+	Monitor ComponentSpec `json:"monitor,omitempty"`
+}
+
+// ComponentSpec is the common shape of a single control-plane component:
+// one Deployment plus a ClusterIP Service for peer/agent discovery.
+type ComponentSpec struct {
+	// Image is the container image for this component. An empty Image
+	// means the component is disabled and is not reconciled.
+	Image string `json:"image,omitempty"`
+
+	// Replicas is the number of replicas for this component.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Port is the port this component listens on.
+	Port int32 `json:"port,omitempty"`
+
+	// Resources are the resource requirements for this component.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Env is the list of environment variables for this component.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// AgentTemplateSpec describes one fleet of agents sharing a role,
+// reconciled into a single child ParallaxAgent object.
+type AgentTemplateSpec struct {
+	// Role names this fleet, e.g. "code-reviewer" or "planner". It seeds
+	// the child ParallaxAgent's name and, when Spec.AgentID is unset, its
+	// AgentID.
+	Role string `json:"role"`
+
+	// Spec is the ParallaxAgentSpec applied to this fleet's child
+	// ParallaxAgent.
+	Spec ParallaxAgentSpec `json:"spec"`
+}
+
+// ParallaxClusterStatus defines the observed state of ParallaxCluster
+type ParallaxClusterStatus struct {
+	// Phase is the aggregate phase of the cluster: Pending when it has no
+	// enabled component and no agent fleet, Running once every component
+	// is Running and every fleet's agents are all available, Failed while
+	// no component is Running and no agent is available (which includes a
+	// newly created cluster before anything has come up), and Degraded
+	// otherwise.
+	Phase ClusterPhase `json:"phase,omitempty"`
+
+	// Conditions represent the latest available observations of the
+	// cluster's components and agent fleets.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// TotalAgents is the sum of Replicas across every agent fleet.
+	TotalAgents int32 `json:"totalAgents,omitempty"`
+
+	// AvailableAgents is the sum of AvailableReplicas across every agent
+	// fleet.
+	AvailableAgents int32 `json:"availableAgents,omitempty"`
+
+	// ComponentStatuses reports the phase of each control-plane
+	// component, keyed by "controlPlane", "scheduler", "registry", or
+	// "monitor".
+	ComponentStatuses map[string]AgentPhase `json:"componentStatuses,omitempty"`
+}
+
+// ClusterPhase represents the aggregate phase of a ParallaxCluster.
+type ClusterPhase string
+
+const (
+	ClusterPending  ClusterPhase = "Pending"
+	ClusterRunning  ClusterPhase = "Running"
+	ClusterDegraded ClusterPhase = "Degraded"
+	ClusterFailed   ClusterPhase = "Failed"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ParallaxClusterList contains a list of ParallaxCluster
+type ParallaxClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ParallaxCluster `json:"items"`
+}
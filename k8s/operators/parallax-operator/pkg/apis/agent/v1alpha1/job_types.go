@@ -0,0 +1,123 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=pxj
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Attempts",type=integer,JSONPath=`.status.attempts`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ParallaxJob is the Schema for the parallaxjobs API. It materializes one
+// SDK parallax.JobSpec/SubmitJob call as a batchv1.Job, for batch/
+// long-running pattern executions (e.g. a Rotate or Correction run) that
+// shouldn't require keeping a ParallaxAgent resident for the duration.
+type ParallaxJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ParallaxJobSpec   `json:"spec,omitempty"`
+	Status ParallaxJobStatus `json:"status,omitempty"`
+}
+
+// ParallaxJobSpec defines the desired state of ParallaxJob, mirroring
+// parallax.JobSpec's fields.
+type ParallaxJobSpec struct {
+	// Type categorizes this job for observability and for DependsOn
+	// chaining; it has no effect on which Pattern is executed.
+	// +kubebuilder:validation:Enum=Analyze;Rotate;Correction;Save
+	Type string `json:"type"`
+
+	// Image is the agent image whose process runs parallax.SubmitJob for
+	// this job's Pattern/Input.
+	Image string `json:"image"`
+
+	// Pattern is the registered PatternExecutor the job's pod drives via
+	// SubmitJob, e.g. "consensus-builder" or "map-reduce".
+	Pattern string `json:"pattern"`
+
+	// InputFrom names the ConfigMap key holding the JSON-encoded Input
+	// passed to SubmitJob, analogous to parallax.JobSpec.Input.
+	InputFrom *corev1.ConfigMapKeySelector `json:"inputFrom,omitempty"`
+
+	// Env is the list of environment variables for the job's pod.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Resources are the resource requirements for the job's pod.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// DependsOn lists ParallaxJob names, in this namespace, that must
+	// reach JobSucceeded before the JobReconciler creates this job's
+	// batchv1.Job. A named job that instead reaches JobFailed fails this
+	// job without creating its batchv1.Job.
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// BackoffLimit caps retry attempts on pod failure, mirroring
+	// batchv1.JobSpec.BackoffLimit and parallax.JobSpec.BackoffLimit.
+	// +kubebuilder:default=0
+	BackoffLimit int32 `json:"backoffLimit,omitempty"`
+
+	// ActiveDeadlineSeconds bounds the underlying batchv1.Job's runtime,
+	// counted from when that Job is created; time spent waiting on
+	// DependsOn is not counted. Zero means no deadline.
+	ActiveDeadlineSeconds int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// TTLSecondsAfterFinished mirrors batchv1.JobSpec's field of the same
+	// name, garbage-collecting the underlying batchv1.Job (but not this
+	// ParallaxJob) that many seconds after it finishes. Nil disables
+	// garbage collection; zero deletes the batchv1.Job as soon as it
+	// finishes.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// ParallaxJobStatus defines the observed state of ParallaxJob
+type ParallaxJobStatus struct {
+	// Phase is the current phase of the job, matching parallax.JobPhase's
+	// values.
+	Phase JobPhase `json:"phase,omitempty"`
+
+	// Attempts is the number of pod attempts the underlying batchv1.Job
+	// has made, mirrored from its status.
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// StartTime is when the JobReconciler created the underlying
+	// batchv1.Job, once DependsOn was satisfied.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the job reached a terminal phase.
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Conditions represent the latest available observations of the
+	// job's underlying batchv1.Job.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Message carries the job's failure reason, if any.
+	Message string `json:"message,omitempty"`
+}
+
+// JobPhase represents the lifecycle phase of a ParallaxJob, matching
+// parallax.JobPhase's values.
+type JobPhase string
+
+const (
+	JobPending   JobPhase = "Pending"
+	JobRunning   JobPhase = "Running"
+	JobSucceeded JobPhase = "Succeeded"
+	JobFailed    JobPhase = "Failed"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ParallaxJobList contains a list of ParallaxJob
+type ParallaxJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ParallaxJob `json:"items"`
+}
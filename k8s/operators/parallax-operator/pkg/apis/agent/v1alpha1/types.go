@@ -51,11 +51,27 @@ type ParallaxAgentSpec struct {
 	// +kubebuilder:default=50051
 	Port int32 `json:"port,omitempty"`
 
+	// MetricsPort is the port the agent's /metrics Prometheus sidecar
+	// listens on, matching parallax.WithMetrics/ParallaxAgent.MetricsAddr's
+	// own ":9090" default. deploymentForAgent always exposes it as a
+	// named "metrics" container port and serviceForAgent mirrors it onto
+	// the Service so a ServiceMonitor can select it by port name.
+	// +kubebuilder:default=9090
+	MetricsPort int32 `json:"metricsPort,omitempty"`
+
 	// HealthCheck is the health check configuration
 	HealthCheck *HealthCheckConfig `json:"healthCheck,omitempty"`
 
 	// Autoscaling is the autoscaling configuration
 	Autoscaling *AutoscalingConfig `json:"autoscaling,omitempty"`
+
+	// EnrollmentTokenRef, when set, names a Secret key holding the
+	// enrollment token this agent's pod uses to bootstrap its identity
+	// via parallax.EnrollAndServe instead of the unauthenticated legacy
+	// Register path. deploymentForAgent mounts it at
+	// /var/run/parallax/enroll and sets PARALLAX_ENROLLMENT_TOKEN_FILE,
+	// so the token is never baked into the agent image.
+	EnrollmentTokenRef *corev1.SecretKeySelector `json:"enrollmentTokenRef,omitempty"`
 }
 
 // HealthCheckConfig defines health check configuration
@@ -119,6 +135,25 @@ type ParallaxAgentStatus struct {
 
 	// Metrics contains agent performance metrics
 	Metrics *AgentMetrics `json:"metrics,omitempty"`
+
+	// Enrollment reports the agent's most recent enrollment-credential
+	// rotation, when EnrollmentTokenRef is set. Populated by the agent
+	// itself via a status-subresource patch after each
+	// parallax.EnrollAndServe Enroll/Heartbeat call, not by this
+	// operator, which has no visibility into the pod's credential state.
+	Enrollment *EnrollmentStatus `json:"enrollment,omitempty"`
+}
+
+// EnrollmentStatus reports an agent's last successful enrollment and its
+// current credential's expiry.
+type EnrollmentStatus struct {
+	// LastEnrolledAt is when the agent last obtained or rotated its
+	// enrollment credential.
+	LastEnrolledAt *metav1.Time `json:"lastEnrolledAt,omitempty"`
+
+	// CredentialExpiresAt is the current credential's expiry, as
+	// returned by EnrollmentService.Enroll/Heartbeat.
+	CredentialExpiresAt *metav1.Time `json:"credentialExpiresAt,omitempty"`
 }
 
 // AgentPhase represents the phase of an agent
@@ -0,0 +1,95 @@
+// Package autoscaling implements a custom-metrics-apiserver
+// ExternalMetricsProvider that exposes confidence-driven scaling signals
+// for ParallaxAgent, plus a hybrid CPU/confidence/queue-depth scorer for
+// scalers that want to combine signals themselves rather than going
+// through the HPA's single-metric external path.
+package autoscaling
+
+import (
+	"context"
+	"sync"
+
+	agentv1alpha1 "github.com/parallax/parallax-operator/pkg/apis/agent/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SmoothingWindow bounds how many AgentMetrics samples are averaged into a
+// Collector's smoothed reading, damping the external metric against the
+// kind of single-execution confidence swing that would otherwise flap an
+// HPA between replica counts.
+const SmoothingWindow = 5
+
+// Sample is one AgentMetrics observation for a ParallaxAgent.
+type Sample struct {
+	AverageConfidence float64
+	ErrorRate         float64
+}
+
+// Collector maintains a smoothed moving average of AgentMetrics per
+// ParallaxAgent, read from status.metrics, so MetricsProvider never blocks
+// an HPA's metric poll on a live read.
+type Collector struct {
+	client client.Client
+
+	mu      sync.RWMutex
+	samples map[types.NamespacedName][]Sample
+}
+
+// NewCollector builds a Collector reading ParallaxAgent status through c.
+func NewCollector(c client.Client) *Collector {
+	return &Collector{client: c, samples: make(map[types.NamespacedName][]Sample)}
+}
+
+// Collect lists every ParallaxAgent in namespace (all namespaces when
+// empty) and folds its current status.metrics into that agent's smoothed
+// window. Callers run this on a fixed interval.
+func (c *Collector) Collect(ctx context.Context, namespace string) error {
+	list := &agentv1alpha1.ParallaxAgentList{}
+	var opts []client.ListOption
+	if namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+	if err := c.client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, agent := range list.Items {
+		if agent.Status.Metrics == nil {
+			continue
+		}
+		key := types.NamespacedName{Namespace: agent.Namespace, Name: agent.Name}
+		sample := Sample{
+			AverageConfidence: agent.Status.Metrics.AverageConfidence,
+			ErrorRate:         agent.Status.Metrics.ErrorRate,
+		}
+		window := append(c.samples[key], sample)
+		if len(window) > SmoothingWindow {
+			window = window[len(window)-SmoothingWindow:]
+		}
+		c.samples[key] = window
+	}
+	return nil
+}
+
+// Smoothed returns the moving average Sample for agent, and false if
+// Collect hasn't observed it yet.
+func (c *Collector) Smoothed(agent types.NamespacedName) (Sample, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	window := c.samples[agent]
+	if len(window) == 0 {
+		return Sample{}, false
+	}
+
+	var sum Sample
+	for _, s := range window {
+		sum.AverageConfidence += s.AverageConfidence
+		sum.ErrorRate += s.ErrorRate
+	}
+	n := float64(len(window))
+	return Sample{AverageConfidence: sum.AverageConfidence / n, ErrorRate: sum.ErrorRate / n}, true
+}
@@ -0,0 +1,85 @@
+package autoscaling
+
+// HybridMetrics is one observation fed into a HybridScaler's Desired
+// calculation.
+type HybridMetrics struct {
+	// CPUUtilizationPercent is the fleet's current average CPU utilization
+	// as a percentage of requested CPU, the same quantity the CPU
+	// ResourceMetricSource reports.
+	CPUUtilizationPercent float64
+
+	// Confidence is the smoothed AverageConfidence (0-1) across the fleet.
+	Confidence float64
+
+	// QueueDepth is the number of pattern executions currently waiting for
+	// an eligible agent.
+	QueueDepth float64
+}
+
+// HybridWeights controls how much each HybridMetrics signal contributes to
+// the combined scale-out pressure. They don't need to sum to 1; Desired
+// normalizes by their sum.
+type HybridWeights struct {
+	CPU        float64
+	Confidence float64
+	QueueDepth float64
+}
+
+// DefaultHybridWeights weighs confidence and queue depth more than CPU, on
+// the premise that a Parallax agent fleet is more often confidence- or
+// backlog-bound than CPU-bound.
+var DefaultHybridWeights = HybridWeights{CPU: 0.2, Confidence: 0.4, QueueDepth: 0.4}
+
+// HybridScaler derives a desired replica count from CPU utilization,
+// confidence deficit, and queue depth combined as a weighted normalized
+// score, for scalers (e.g. KEDA ScaledObjects) that can combine multiple
+// signals directly instead of going through a single HPA external metric.
+type HybridScaler struct {
+	Weights HybridWeights
+
+	TargetCPUUtilizationPercent float64
+	TargetConfidence            float64
+	TargetQueueDepth            float64
+}
+
+// Desired returns the replica count HybridScaler recommends for
+// currentReplicas given metrics, clamped to [minReplicas, maxReplicas].
+func (s *HybridScaler) Desired(currentReplicas int32, metrics HybridMetrics, minReplicas, maxReplicas int32) int32 {
+	cpuRatio := ratioTo(metrics.CPUUtilizationPercent, s.TargetCPUUtilizationPercent)
+	queueRatio := ratioTo(metrics.QueueDepth, s.TargetQueueDepth)
+
+	confidenceDeficit := s.TargetConfidence - metrics.Confidence
+	if confidenceDeficit < 0 {
+		confidenceDeficit = 0
+	}
+	target := s.TargetConfidence
+	if target <= 0 {
+		target = 0.01
+	}
+	confidenceRatio := 1 + confidenceDeficit/target
+
+	totalWeight := s.Weights.CPU + s.Weights.Confidence + s.Weights.QueueDepth
+	if totalWeight == 0 {
+		totalWeight = 1
+	}
+	combinedRatio := (s.Weights.CPU*cpuRatio + s.Weights.Confidence*confidenceRatio + s.Weights.QueueDepth*queueRatio) / totalWeight
+
+	desired := int32(float64(currentReplicas)*combinedRatio + 0.5)
+	if desired < minReplicas {
+		desired = minReplicas
+	}
+	if desired > maxReplicas {
+		desired = maxReplicas
+	}
+	return desired
+}
+
+// ratioTo returns current/target, the contribution a single HPA-style
+// metric makes to combinedRatio, treating a non-positive target as "this
+// signal is disabled" (a neutral 1).
+func ratioTo(current, target float64) float64 {
+	if target <= 0 {
+		return 1
+	}
+	return current / target
+}
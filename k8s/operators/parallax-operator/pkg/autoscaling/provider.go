@@ -0,0 +1,131 @@
+package autoscaling
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+)
+
+// ConfidenceMetricName is the external.metrics.k8s.io metric name an HPA
+// targets to scale a ParallaxAgent on aggregated confidence.
+//
+// Its value is published as a confidence *deficit*
+// (TargetConfidenceThreshold - smoothed confidence, floored at zero)
+// rather than raw confidence. The standard HPA formula
+// (desiredReplicas = ceil(currentReplicas * currentValue / targetValue))
+// only scales up as a metric's value increases, but for confidence lower
+// is worse — so the signal has to be inverted for "confidence dropped"
+// to mean "scale up" the way more replicas (and more diverse opinions)
+// is meant to push confidence back up.
+const ConfidenceMetricName = "parallax_agent_confidence"
+
+// agentLabel selects which ParallaxAgent a GetExternalMetric call is
+// asking about, since the external metrics API has no notion of an object
+// reference beyond namespace + a label selector.
+const agentLabel = "agent"
+
+// defaultConfidenceThreshold is used when SetThreshold hasn't been called
+// for an agent yet: GetExternalMetric then reports the deficit against
+// 0.8, since AutoscalingConfig.TargetConfidenceThreshold has no default of
+// its own.
+const defaultConfidenceThreshold = 0.8
+
+// MetricsProvider implements custom-metrics-apiserver's
+// provider.ExternalMetricsProvider, serving ConfidenceMetricName from a
+// Collector's smoothed AgentMetrics.
+type MetricsProvider struct {
+	collector *Collector
+
+	mu         sync.RWMutex
+	thresholds map[types.NamespacedName]float64
+}
+
+// NewMetricsProvider builds a MetricsProvider reading smoothed samples
+// from collector.
+func NewMetricsProvider(collector *Collector) *MetricsProvider {
+	return &MetricsProvider{collector: collector, thresholds: make(map[types.NamespacedName]float64)}
+}
+
+// SetThreshold records agent's TargetConfidenceThreshold, read back by
+// GetExternalMetric to compute the deficit. The operator calls this every
+// time it reconciles a ParallaxAgent with autoscaling enabled.
+func (p *MetricsProvider) SetThreshold(agent types.NamespacedName, threshold float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.thresholds[agent] = threshold
+}
+
+func (p *MetricsProvider) threshold(agent types.NamespacedName) float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if t, ok := p.thresholds[agent]; ok {
+		return t
+	}
+	return defaultConfidenceThreshold
+}
+
+// GetExternalMetric implements provider.ExternalMetricsProvider.
+func (p *MetricsProvider) GetExternalMetric(ctx context.Context, namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	if info.Metric != ConfidenceMetricName {
+		return nil, fmt.Errorf("autoscaling: metric %q not supported", info.Metric)
+	}
+
+	agentName, ok := requireLabel(metricSelector, agentLabel)
+	if !ok {
+		return nil, fmt.Errorf("autoscaling: external metric %q requires an %q label selector", ConfidenceMetricName, agentLabel)
+	}
+	key := types.NamespacedName{Namespace: namespace, Name: agentName}
+
+	sample, ok := p.collector.Smoothed(key)
+	if !ok {
+		return nil, fmt.Errorf("autoscaling: no confidence samples collected yet for agent %s/%s", namespace, agentName)
+	}
+
+	deficit := p.threshold(key) - sample.AverageConfidence
+	if deficit < 0 {
+		deficit = 0
+	}
+
+	return &external_metrics.ExternalMetricValueList{
+		Items: []external_metrics.ExternalMetricValue{
+			{
+				MetricName:   ConfidenceMetricName,
+				MetricLabels: map[string]string{agentLabel: agentName},
+				Timestamp:    metav1.Now(),
+				Value:        *resource.NewMilliQuantity(int64(deficit*1000), resource.DecimalSI),
+			},
+		},
+	}, nil
+}
+
+// ListAllExternalMetrics implements provider.ExternalMetricsProvider.
+func (p *MetricsProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	return []provider.ExternalMetricInfo{{Metric: ConfidenceMetricName}}
+}
+
+// requireLabel extracts sel's exact-match value for key, the way an HPA's
+// external metric Selector.matchLabels is expected to be built.
+func requireLabel(sel labels.Selector, key string) (string, bool) {
+	requirements, selectable := sel.Requirements()
+	if !selectable {
+		return "", false
+	}
+	for _, req := range requirements {
+		if req.Key() != key {
+			continue
+		}
+		values := req.Values().List()
+		if len(values) != 1 {
+			return "", false
+		}
+		return values[0], true
+	}
+	return "", false
+}
@@ -3,11 +3,15 @@ package agent
 import (
 	"context"
 	"fmt"
+	"time"
 
 	agentv1alpha1 "github.com/parallax/parallax-operator/pkg/apis/agent/v1alpha1"
+	"github.com/parallax/parallax-operator/pkg/autoscaling"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -17,12 +21,41 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// healthCheckedServices are the services probed against each agent's
+// grpc.health.v1.Health endpoint, matching the SDK's well-known service
+// names (parallax.PatternService, parallax.AgentService,
+// parallax.ExecutionService) so partial degradation is visible per-service
+// in status.conditions.
+var healthCheckedServices = []string{
+	"parallax.PatternService",
+	"parallax.AgentService",
+	"parallax.ExecutionService",
+}
+
+const defaultHealthCheckInterval = 30 * time.Second
+
+// enrollmentTokenMountPath is where the enrollment token Secret named by
+// agent.Spec.EnrollmentTokenRef is mounted, matching
+// parallax.EnrollConfig.TokenFile's expected location.
+const enrollmentTokenMountPath = "/var/run/parallax/enroll"
+
 // AgentReconciler reconciles a ParallaxAgent object
 type AgentReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// MetricsProvider, when set, has its confidence threshold kept in sync
+	// with every reconciled ParallaxAgent's Autoscaling.TargetConfidenceThreshold,
+	// so the external metrics API it serves reflects the agent's current
+	// spec. Nil disables confidence-based HPA metrics entirely (the HPA
+	// created still scales on CPU if TargetCPUUtilizationPercentage is set).
+	MetricsProvider *autoscaling.MetricsProvider
 }
 
 // +kubebuilder:rbac:groups=agent.parallax.io,resources=parallaxagents,verbs=get;list;watch;create;update;patch;delete
@@ -30,6 +63,7 @@ type AgentReconciler struct {
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile reads the state of the cluster for a ParallaxAgent object and makes changes
 func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -97,6 +131,11 @@ func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		return reconcile.Result{}, err
 	}
 
+	// Reconcile HorizontalPodAutoscaler
+	if err := r.reconcileHPA(ctx, agent); err != nil {
+		return reconcile.Result{}, fmt.Errorf("reconcile HPA: %w", err)
+	}
+
 	// Update status
 	agent.Status.Phase = agentv1alpha1.AgentRunning
 	agent.Status.Replicas = *agent.Spec.Replicas
@@ -113,13 +152,112 @@ func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		agent.Status.AvailableReplicas = deployment.Status.AvailableReplicas
 	}
 
+	requeueAfter := time.Duration(0)
+	if agent.Spec.HealthCheck != nil && agent.Spec.HealthCheck.Enabled && agent.Status.Endpoint != "" {
+		agent.Status.Conditions = r.probeHealth(ctx, agent)
+		requeueAfter = healthCheckInterval(agent.Spec.HealthCheck)
+	}
+
 	err = r.Status().Update(ctx, agent)
 	if err != nil {
 		log.Error(err, "Failed to update agent status")
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// healthCheckInterval parses cfg.Interval, falling back to
+// defaultHealthCheckInterval when it's empty or invalid.
+func healthCheckInterval(cfg *agentv1alpha1.HealthCheckConfig) time.Duration {
+	if cfg.Interval == "" {
+		return defaultHealthCheckInterval
+	}
+	d, err := time.ParseDuration(cfg.Interval)
+	if err != nil {
+		return defaultHealthCheckInterval
+	}
+	return d
+}
+
+// probeHealth checks every entry in healthCheckedServices against agent's
+// grpc.health.v1.Health endpoint and returns the resulting per-service and
+// aggregate Ready conditions. A service that can't be reached at all is
+// reported not ready (reason "Unreachable") rather than failing the whole
+// reconcile.
+func (r *AgentReconciler) probeHealth(ctx context.Context, agent *agentv1alpha1.ParallaxAgent) []metav1.Condition {
+	dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	conn, err := grpc.NewClient(agent.Status.Endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return []metav1.Condition{notReadyCondition(fmt.Sprintf("failed to dial agent: %v", err))}
+	}
+	defer conn.Close()
+
+	healthClient := healthpb.NewHealthClient(conn)
+
+	conditions := make([]metav1.Condition, 0, len(healthCheckedServices)+1)
+	allServing := true
+	for _, service := range healthCheckedServices {
+		status := metav1.ConditionFalse
+		reason := "NotServing"
+		resp, err := healthClient.Check(dialCtx, &healthpb.HealthCheckRequest{Service: service})
+		switch {
+		case err != nil:
+			reason = "Unreachable"
+			allServing = false
+		case resp.Status == healthpb.HealthCheckResponse_SERVING:
+			status = metav1.ConditionTrue
+			reason = "Serving"
+		default:
+			allServing = false
+		}
+		conditions = append(conditions, metav1.Condition{
+			Type:               service + "Ready",
+			Status:             status,
+			Reason:             reason,
+			Message:            fmt.Sprintf("health check for %s", service),
+			LastTransitionTime: metav1.Now(),
+		})
+	}
+
+	if allServing {
+		conditions = append(conditions, metav1.Condition{
+			Type:               "Ready",
+			Status:             metav1.ConditionTrue,
+			Reason:             "AllServicesServing",
+			Message:            "all probed services are SERVING",
+			LastTransitionTime: metav1.Now(),
+		})
+	} else {
+		conditions = append(conditions, notReadyCondition("one or more services are not SERVING"))
+	}
+
+	return conditions
+}
+
+// notReadyCondition builds the aggregate Ready=False condition used both
+// when the agent can't be reached at all and when a probed service isn't
+// SERVING.
+func notReadyCondition(message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionFalse,
+		Reason:             "NotReady",
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	}
+}
+
+// metricsPort returns agent.Spec.MetricsPort, defaulting to 9090 (matching
+// parallax.WithMetrics/ParallaxAgent.MetricsAddr's own default) for specs
+// created before MetricsPort existed.
+func metricsPort(agent *agentv1alpha1.ParallaxAgent) int32 {
+	if agent.Spec.MetricsPort == 0 {
+		return 9090
+	}
+	return agent.Spec.MetricsPort
 }
 
 // deploymentForAgent returns a Deployment object for the agent
@@ -160,6 +298,11 @@ func (r *AgentReconciler) deploymentForAgent(agent *agentv1alpha1.ParallaxAgent)
 									ContainerPort: agent.Spec.Port,
 									Protocol:      corev1.ProtocolTCP,
 								},
+								{
+									Name:          "metrics",
+									ContainerPort: metricsPort(agent),
+									Protocol:      corev1.ProtocolTCP,
+								},
 							},
 							Env: append(agent.Spec.Env, 
 								corev1.EnvVar{
@@ -205,6 +348,35 @@ func (r *AgentReconciler) deploymentForAgent(agent *agentv1alpha1.ParallaxAgent)
 		}
 	}
 
+	// Mount the enrollment token so the agent's parallax.EnrollAndServe
+	// call can bootstrap its identity without the token ever being
+	// baked into the image.
+	if agent.Spec.EnrollmentTokenRef != nil {
+		const volumeName = "parallax-enroll"
+		container := &deployment.Spec.Template.Spec.Containers[0]
+
+		deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: agent.Spec.EnrollmentTokenRef.Name,
+					Items: []corev1.KeyToPath{
+						{Key: agent.Spec.EnrollmentTokenRef.Key, Path: agent.Spec.EnrollmentTokenRef.Key},
+					},
+				},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: enrollmentTokenMountPath,
+			ReadOnly:  true,
+		})
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  "PARALLAX_ENROLLMENT_TOKEN_FILE",
+			Value: enrollmentTokenMountPath + "/" + agent.Spec.EnrollmentTokenRef.Key,
+		})
+	}
+
 	return deployment
 }
 
@@ -230,17 +402,132 @@ func (r *AgentReconciler) serviceForAgent(agent *agentv1alpha1.ParallaxAgent) *c
 					TargetPort: intstr.FromInt(int(agent.Spec.Port)),
 					Protocol:   corev1.ProtocolTCP,
 				},
+				{
+					// Named "metrics" so a ServiceMonitor can select this
+					// port by name instead of needing to know the value.
+					Name:       "metrics",
+					Port:       metricsPort(agent),
+					TargetPort: intstr.FromInt(int(metricsPort(agent))),
+					Protocol:   corev1.ProtocolTCP,
+				},
 			},
 			Type: corev1.ServiceTypeClusterIP,
 		},
 	}
 }
 
+// reconcileHPA creates or updates the HorizontalPodAutoscaler backing
+// agent's Deployment when Autoscaling.Enabled, and removes it otherwise.
+// TargetCPUUtilizationPercentage contributes a standard Resource metric;
+// TargetConfidenceThreshold contributes an External metric targeting
+// autoscaling.ConfidenceMetricName, scoped to this agent via an "agent"
+// label selector.
+func (r *AgentReconciler) reconcileHPA(ctx context.Context, agent *agentv1alpha1.ParallaxAgent) error {
+	cfg := agent.Spec.Autoscaling
+	if cfg == nil || !cfg.Enabled {
+		return r.deleteHPAIfExists(ctx, agent)
+	}
+
+	if cfg.TargetConfidenceThreshold != nil && r.MetricsProvider != nil {
+		r.MetricsProvider.SetThreshold(types.NamespacedName{Namespace: agent.Namespace, Name: agent.Name}, *cfg.TargetConfidenceThreshold)
+	}
+
+	minReplicas := cfg.MinReplicas
+	if minReplicas == 0 {
+		minReplicas = 1
+	}
+	maxReplicas := cfg.MaxReplicas
+	if maxReplicas == 0 {
+		maxReplicas = 10
+	}
+
+	var metrics []autoscalingv2.MetricSpec
+	if cfg.TargetCPUUtilizationPercentage > 0 {
+		cpuTarget := cfg.TargetCPUUtilizationPercentage
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: corev1.ResourceCPU,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: &cpuTarget,
+				},
+			},
+		})
+	}
+	if cfg.TargetConfidenceThreshold != nil && r.MetricsProvider != nil {
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ExternalMetricSourceType,
+			External: &autoscalingv2.ExternalMetricSource{
+				Metric: autoscalingv2.MetricIdentifier{
+					Name:     autoscaling.ConfidenceMetricName,
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"agent": agent.Name}},
+				},
+				// The provider already publishes the confidence deficit
+				// (see autoscaling.ConfidenceMetricName), so any nonzero
+				// value should drive scale-up; target a near-zero value
+				// rather than the raw threshold.
+				Target: autoscalingv2.MetricTarget{
+					Type:         autoscalingv2.AverageValueMetricType,
+					AverageValue: resource.NewMilliQuantity(1, resource.DecimalSI),
+				},
+			},
+		})
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      agent.Name + "-hpa",
+			Namespace: agent.Namespace,
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       agent.Name + "-deployment",
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: maxReplicas,
+			Metrics:     metrics,
+		},
+	}
+	if err := controllerutil.SetControllerReference(agent, hpa, r.Scheme); err != nil {
+		return err
+	}
+
+	found := &autoscalingv2.HorizontalPodAutoscaler{}
+	err := r.Get(ctx, types.NamespacedName{Name: hpa.Name, Namespace: hpa.Namespace}, found)
+	switch {
+	case errors.IsNotFound(err):
+		return r.Create(ctx, hpa)
+	case err != nil:
+		return err
+	}
+
+	found.Spec = hpa.Spec
+	return r.Update(ctx, found)
+}
+
+// deleteHPAIfExists removes agent's HorizontalPodAutoscaler, if any, for
+// when Autoscaling.Enabled has been turned off.
+func (r *AgentReconciler) deleteHPAIfExists(ctx context.Context, agent *agentv1alpha1.ParallaxAgent) error {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	err := r.Get(ctx, types.NamespacedName{Name: agent.Name + "-hpa", Namespace: agent.Namespace}, hpa)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return r.Delete(ctx, hpa)
+}
+
 // SetupWithManager sets up the controller with the Manager
 func (r *AgentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&agentv1alpha1.ParallaxAgent{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
 		Complete(r)
 }
\ No newline at end of file
@@ -0,0 +1,300 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	agentv1alpha1 "github.com/parallax/parallax-operator/pkg/apis/agent/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ClusterReconciler reconciles a ParallaxCluster object
+type ClusterReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=agent.parallax.io,resources=parallaxclusters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=agent.parallax.io,resources=parallaxclusters/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=agent.parallax.io,resources=parallaxagents,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+
+// componentOrder lists the ComponentsSpec fields in reconcile order: the
+// registry comes up before the pieces that depend on reaching it.
+var componentOrder = []string{"registry", "controlPlane", "scheduler", "monitor"}
+
+// Reconcile reads the state of the cluster for a ParallaxCluster object,
+// reconciles its control-plane components and agent fleets, and
+// aggregates their statuses into the cluster-level Phase and Conditions.
+func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	cluster := &agentv1alpha1.ParallaxCluster{}
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	componentStatuses := make(map[string]agentv1alpha1.AgentPhase, len(componentOrder))
+	for _, name := range componentOrder {
+		spec := componentSpec(&cluster.Spec.Components, name)
+		if spec.Image == "" {
+			continue
+		}
+		phase, err := r.reconcileComponent(ctx, cluster, name, spec)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("reconcile component %s: %w", name, err)
+		}
+		componentStatuses[name] = phase
+	}
+
+	var totalAgents, availableAgents int32
+	for _, tmpl := range cluster.Spec.AgentTemplates {
+		agent, err := r.reconcileAgentFleet(ctx, cluster, tmpl)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("reconcile agent fleet %s: %w", tmpl.Role, err)
+		}
+		totalAgents += agent.Status.Replicas
+		availableAgents += agent.Status.AvailableReplicas
+	}
+
+	cluster.Status.ComponentStatuses = componentStatuses
+	cluster.Status.TotalAgents = totalAgents
+	cluster.Status.AvailableAgents = availableAgents
+	cluster.Status.Phase = aggregatePhase(componentStatuses, len(cluster.Spec.AgentTemplates), totalAgents, availableAgents)
+	cluster.Status.Conditions = aggregateConditions(cluster.Status.Phase, componentStatuses)
+
+	if err := r.Status().Update(ctx, cluster); err != nil {
+		log.Error(err, "Failed to update cluster status")
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// componentSpec returns the named field of components, matching
+// componentOrder's entries ("registry", "controlPlane", "scheduler",
+// "monitor").
+func componentSpec(components *agentv1alpha1.ComponentsSpec, name string) *agentv1alpha1.ComponentSpec {
+	switch name {
+	case "controlPlane":
+		return &components.ControlPlane
+	case "scheduler":
+		return &components.Scheduler
+	case "registry":
+		return &components.Registry
+	case "monitor":
+		return &components.Monitor
+	default:
+		return &agentv1alpha1.ComponentSpec{}
+	}
+}
+
+// reconcileComponent creates or updates the Deployment and ClusterIP
+// Service backing a single control-plane component and returns its
+// observed phase.
+func (r *ClusterReconciler) reconcileComponent(ctx context.Context, cluster *agentv1alpha1.ParallaxCluster, name string, spec *agentv1alpha1.ComponentSpec) (agentv1alpha1.AgentPhase, error) {
+	replicas := spec.Replicas
+	if replicas == nil {
+		one := int32(1)
+		replicas = &one
+	}
+
+	labels := map[string]string{
+		"app":       "parallax-cluster",
+		"cluster":   cluster.Name,
+		"component": name,
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", cluster.Name, name),
+			Namespace: cluster.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  name,
+							Image: spec.Image,
+							Ports: []corev1.ContainerPort{
+								{Name: "grpc", ContainerPort: spec.Port, Protocol: corev1.ProtocolTCP},
+							},
+							Env:       spec.Env,
+							Resources: spec.Resources,
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(cluster, deployment, r.Scheme); err != nil {
+		return agentv1alpha1.AgentFailed, err
+	}
+
+	foundDeployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	switch {
+	case errors.IsNotFound(err):
+		if err := r.Create(ctx, deployment); err != nil {
+			return agentv1alpha1.AgentFailed, err
+		}
+		foundDeployment = deployment
+	case err != nil:
+		return agentv1alpha1.AgentFailed, err
+	case *foundDeployment.Spec.Replicas != *replicas:
+		foundDeployment.Spec.Replicas = replicas
+		if err := r.Update(ctx, foundDeployment); err != nil {
+			return agentv1alpha1.AgentFailed, err
+		}
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", cluster.Name, name),
+			Namespace: cluster.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector:  labels,
+			Ports:     []corev1.ServicePort{{Name: "grpc", Port: spec.Port, TargetPort: intstr.FromInt(int(spec.Port)), Protocol: corev1.ProtocolTCP}},
+			ClusterIP: corev1.ClusterIPNone,
+		},
+	}
+	if err := controllerutil.SetControllerReference(cluster, service, r.Scheme); err != nil {
+		return agentv1alpha1.AgentFailed, err
+	}
+
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if errors.IsNotFound(err) {
+		if err := r.Create(ctx, service); err != nil {
+			return agentv1alpha1.AgentFailed, err
+		}
+	} else if err != nil {
+		return agentv1alpha1.AgentFailed, err
+	}
+
+	if foundDeployment.Status.AvailableReplicas >= *replicas {
+		return agentv1alpha1.AgentRunning, nil
+	}
+	return agentv1alpha1.AgentPending, nil
+}
+
+// reconcileAgentFleet creates or updates the child ParallaxAgent backing
+// one AgentTemplateSpec and returns it with its latest observed status.
+func (r *ClusterReconciler) reconcileAgentFleet(ctx context.Context, cluster *agentv1alpha1.ParallaxCluster, tmpl agentv1alpha1.AgentTemplateSpec) (*agentv1alpha1.ParallaxAgent, error) {
+	spec := tmpl.Spec
+	if spec.AgentID == "" {
+		spec.AgentID = tmpl.Role
+	}
+
+	agent := &agentv1alpha1.ParallaxAgent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", cluster.Name, tmpl.Role),
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				"app":     "parallax-cluster",
+				"cluster": cluster.Name,
+				"role":    tmpl.Role,
+			},
+		},
+		Spec: spec,
+	}
+	if err := controllerutil.SetControllerReference(cluster, agent, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	found := &agentv1alpha1.ParallaxAgent{}
+	err := r.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace}, found)
+	switch {
+	case errors.IsNotFound(err):
+		if err := r.Create(ctx, agent); err != nil {
+			return nil, err
+		}
+		return agent, nil
+	case err != nil:
+		return nil, err
+	}
+
+	found.Spec = spec
+	if err := r.Update(ctx, found); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// aggregatePhase rolls component and agent fleet health up into one
+// cluster-level phase.
+func aggregatePhase(componentStatuses map[string]agentv1alpha1.AgentPhase, agentFleets int, totalAgents, availableAgents int32) agentv1alpha1.ClusterPhase {
+	if len(componentStatuses) == 0 && agentFleets == 0 {
+		return agentv1alpha1.ClusterPending
+	}
+
+	healthyComponents := 0
+	for _, phase := range componentStatuses {
+		if phase == agentv1alpha1.AgentRunning {
+			healthyComponents++
+		}
+	}
+
+	agentsHealthy := agentFleets == 0 || (totalAgents > 0 && availableAgents == totalAgents)
+	componentsHealthy := healthyComponents == len(componentStatuses)
+
+	switch {
+	case componentsHealthy && agentsHealthy:
+		return agentv1alpha1.ClusterRunning
+	case healthyComponents == 0 && availableAgents == 0:
+		return agentv1alpha1.ClusterFailed
+	default:
+		return agentv1alpha1.ClusterDegraded
+	}
+}
+
+// aggregateConditions summarizes componentStatuses into cluster-level
+// Conditions, one per component.
+func aggregateConditions(phase agentv1alpha1.ClusterPhase, componentStatuses map[string]agentv1alpha1.AgentPhase) []metav1.Condition {
+	conditions := make([]metav1.Condition, 0, len(componentStatuses))
+	for name, componentPhase := range componentStatuses {
+		status := metav1.ConditionFalse
+		if componentPhase == agentv1alpha1.AgentRunning {
+			status = metav1.ConditionTrue
+		}
+		conditions = append(conditions, metav1.Condition{
+			Type:               name + "Ready",
+			Status:             status,
+			Reason:             string(componentPhase),
+			Message:            fmt.Sprintf("component %s is %s", name, componentPhase),
+			LastTransitionTime: metav1.Now(),
+		})
+	}
+	return conditions
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&agentv1alpha1.ParallaxCluster{}).
+		Owns(&agentv1alpha1.ParallaxAgent{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Complete(r)
+}
@@ -0,0 +1,254 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	agentv1alpha1 "github.com/parallax/parallax-operator/pkg/apis/agent/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// dependencyPollInterval is how often Reconcile re-checks an unready
+// job's DependsOn. statusPollInterval is how often it re-reads the
+// underlying batchv1.Job's status while the job is Running; both fall
+// back to this polling because there's no event source wired from a
+// dependency/child Job's status change to this ParallaxJob's queue.
+const (
+	dependencyPollInterval = 10 * time.Second
+	statusPollInterval     = 10 * time.Second
+)
+
+// JobReconciler reconciles a ParallaxJob object
+type JobReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=agent.parallax.io,resources=parallaxjobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=agent.parallax.io,resources=parallaxjobs/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile waits for job's DependsOn to succeed, materializes its
+// batchv1.Job once they have, and mirrors that Job's status back onto
+// job.Status until it reaches a terminal phase.
+func (r *JobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	job := &agentv1alpha1.ParallaxJob{}
+	if err := r.Get(ctx, req.NamespacedName, job); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if job.Status.Phase == agentv1alpha1.JobSucceeded || job.Status.Phase == agentv1alpha1.JobFailed {
+		return reconcile.Result{}, nil
+	}
+
+	ready, err := r.dependenciesReady(ctx, job)
+	if err != nil {
+		job.Status.Phase = agentv1alpha1.JobFailed
+		job.Status.Message = err.Error()
+		job.Status.CompletionTime = timePtr(metav1.Now())
+		if uerr := r.Status().Update(ctx, job); uerr != nil {
+			return reconcile.Result{}, uerr
+		}
+		return reconcile.Result{}, nil
+	}
+	if !ready {
+		job.Status.Phase = agentv1alpha1.JobPending
+		if uerr := r.Status().Update(ctx, job); uerr != nil {
+			return reconcile.Result{}, uerr
+		}
+		return reconcile.Result{RequeueAfter: dependencyPollInterval}, nil
+	}
+
+	batchJob := r.batchJobForJob(job)
+	if err := controllerutil.SetControllerReference(job, batchJob, r.Scheme); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	found := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Name: batchJob.Name, Namespace: batchJob.Namespace}, found)
+	switch {
+	case errors.IsNotFound(err):
+		log.Info("Creating batch Job", "job", batchJob.Name)
+		if err := r.Create(ctx, batchJob); err != nil {
+			return reconcile.Result{}, err
+		}
+		job.Status.Phase = agentv1alpha1.JobRunning
+		job.Status.StartTime = timePtr(metav1.Now())
+		if err := r.Status().Update(ctx, job); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: statusPollInterval}, nil
+	case err != nil:
+		return reconcile.Result{}, err
+	}
+
+	job.Status.Attempts = found.Status.Failed + found.Status.Succeeded
+	job.Status.Conditions = batchJobConditions(found)
+
+	switch {
+	case found.Status.Succeeded > 0:
+		job.Status.Phase = agentv1alpha1.JobSucceeded
+		job.Status.CompletionTime = found.Status.CompletionTime
+	case found.Status.Failed > 0 && batchJobExhausted(found, job.Spec.BackoffLimit):
+		job.Status.Phase = agentv1alpha1.JobFailed
+		job.Status.Message = "batch Job exhausted its backoff limit"
+		job.Status.CompletionTime = timePtr(metav1.Now())
+	default:
+		job.Status.Phase = agentv1alpha1.JobRunning
+	}
+
+	if err := r.Status().Update(ctx, job); err != nil {
+		log.Error(err, "Failed to update job status")
+		return reconcile.Result{}, err
+	}
+
+	if job.Status.Phase == agentv1alpha1.JobRunning {
+		return reconcile.Result{RequeueAfter: statusPollInterval}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// dependenciesReady reports whether every name in job.Spec.DependsOn
+// names a ParallaxJob, in job's namespace, that has reached
+// JobSucceeded, returning an error as soon as one of them instead reached
+// JobFailed (or doesn't exist) rather than waiting on the rest.
+func (r *JobReconciler) dependenciesReady(ctx context.Context, job *agentv1alpha1.ParallaxJob) (bool, error) {
+	for _, name := range job.Spec.DependsOn {
+		dep := &agentv1alpha1.ParallaxJob{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: job.Namespace}, dep)
+		if errors.IsNotFound(err) {
+			return false, fmt.Errorf("dependency job not found: %s", name)
+		}
+		if err != nil {
+			return false, err
+		}
+		switch dep.Status.Phase {
+		case agentv1alpha1.JobSucceeded:
+			continue
+		case agentv1alpha1.JobFailed:
+			return false, fmt.Errorf("dependency job %s did not succeed", name)
+		default:
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// batchJobForJob returns the batchv1.Job that runs job's pod. The pod's
+// container invokes the agent image's standard entrypoint, which dials
+// the registry and calls parallax.SubmitJob with Spec/Pattern/Input read
+// from its environment, mirroring deploymentForAgent's AGENT_ID/
+// PARALLAX_REGISTRY env convention.
+func (r *JobReconciler) batchJobForJob(job *agentv1alpha1.ParallaxJob) *batchv1.Job {
+	labels := map[string]string{
+		"app": "parallax-job",
+		"job": job.Name,
+	}
+
+	env := append([]corev1.EnvVar{}, job.Spec.Env...)
+	env = append(env,
+		corev1.EnvVar{Name: "PARALLAX_JOB_TYPE", Value: job.Spec.Type},
+		corev1.EnvVar{Name: "PARALLAX_JOB_PATTERN", Value: job.Spec.Pattern},
+		corev1.EnvVar{Name: "PARALLAX_REGISTRY", Value: "parallax-control-plane:2379"},
+	)
+	if job.Spec.InputFrom != nil {
+		env = append(env, corev1.EnvVar{
+			Name: "PARALLAX_JOB_INPUT",
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: job.Spec.InputFrom,
+			},
+		})
+	}
+
+	backoffLimit := job.Spec.BackoffLimit
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-job",
+			Namespace: job.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			ActiveDeadlineSeconds:   nonZeroInt64Ptr(job.Spec.ActiveDeadlineSeconds),
+			TTLSecondsAfterFinished: job.Spec.TTLSecondsAfterFinished,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:      "job",
+							Image:     job.Spec.Image,
+							Env:       env,
+							Resources: job.Spec.Resources,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// nonZeroInt64Ptr returns nil for a zero seconds value (batchv1.Job's own
+// "no deadline" convention) and a pointer to seconds otherwise.
+func nonZeroInt64Ptr(seconds int64) *int64 {
+	if seconds == 0 {
+		return nil
+	}
+	return &seconds
+}
+
+// timePtr returns a pointer to t, for assigning metav1.Now() directly
+// into the *metav1.Time status fields.
+func timePtr(t metav1.Time) *metav1.Time {
+	return &t
+}
+
+// batchJobExhausted reports whether batchJob's failed-pod count has
+// reached backoffLimit+1 attempts, matching batchv1.Job's own
+// BackoffLimitExceeded semantics.
+func batchJobExhausted(batchJob *batchv1.Job, backoffLimit int32) bool {
+	return batchJob.Status.Failed > backoffLimit
+}
+
+// batchJobConditions mirrors batchJob's own Conditions onto the
+// ParallaxJob, so `kubectl describe` on either object shows the same
+// history.
+func batchJobConditions(batchJob *batchv1.Job) []metav1.Condition {
+	conditions := make([]metav1.Condition, 0, len(batchJob.Status.Conditions))
+	for _, c := range batchJob.Status.Conditions {
+		conditions = append(conditions, metav1.Condition{
+			Type:               string(c.Type),
+			Status:             metav1.ConditionStatus(c.Status),
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime,
+		})
+	}
+	return conditions
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *JobReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&agentv1alpha1.ParallaxJob{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
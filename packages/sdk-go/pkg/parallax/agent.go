@@ -1,201 +0,0 @@
-package parallax
-
-import (
-	"context"
-	"fmt"
-
-	"go.uber.org/zap"
-)
-
-// agentService implements AgentService
-type agentService struct {
-	client *Client
-	logger *zap.Logger
-}
-
-// Register registers a new agent
-func (s *agentService) Register(ctx context.Context, agent *AgentInfo) error {
-	s.logger.Info("Registering agent",
-		zap.String("id", agent.ID),
-		zap.String("name", agent.Name),
-		zap.Strings("capabilities", agent.Capabilities),
-	)
-	
-	if agent.ID == "" {
-		agent.ID = generateID()
-	}
-	
-	if agent.Status == "" {
-		agent.Status = AgentStatusActive
-	}
-	
-	if agent.Confidence == 0 {
-		agent.Confidence = 0.8
-	}
-	
-	agent.LastSeen = timeNow()
-	
-	// TODO: Implement gRPC call
-	
-	return nil
-}
-
-// List returns all registered agents
-func (s *agentService) List(ctx context.Context) ([]*AgentInfo, error) {
-	s.logger.Debug("Listing agents")
-	
-	// TODO: Implement gRPC call
-	
-	// Mock implementation
-	return []*AgentInfo{
-		{
-			ID:           "agent-1",
-			Name:         "Sentiment Analyzer",
-			Status:       AgentStatusActive,
-			Capabilities: []string{"sentiment", "analysis"},
-			Endpoint:     "localhost:50051",
-			LastSeen:     timeNow(),
-			Confidence:   0.85,
-			Metadata: map[string]string{
-				"version": "1.0.0",
-				"region":  "us-east-1",
-			},
-		},
-		{
-			ID:           "agent-2",
-			Name:         "Data Processor",
-			Status:       AgentStatusActive,
-			Capabilities: []string{"processing", "transform"},
-			Endpoint:     "localhost:50052",
-			LastSeen:     timeNow().Add(-30 * timeSecond),
-			Confidence:   0.92,
-		},
-		{
-			ID:           "agent-3",
-			Name:         "ML Predictor",
-			Status:       AgentStatusInactive,
-			Capabilities: []string{"prediction", "ml"},
-			Endpoint:     "localhost:50053",
-			LastSeen:     timeNow().Add(-5 * timeMinute),
-			Confidence:   0.78,
-		},
-	}, nil
-}
-
-// Get returns a specific agent by ID
-func (s *agentService) Get(ctx context.Context, id string) (*AgentInfo, error) {
-	s.logger.Debug("Getting agent", zap.String("id", id))
-	
-	agents, err := s.List(ctx)
-	if err != nil {
-		return nil, err
-	}
-	
-	for _, agent := range agents {
-		if agent.ID == id {
-			return agent, nil
-		}
-	}
-	
-	return nil, fmt.Errorf("agent not found: %s", id)
-}
-
-// UpdateStatus updates an agent's status
-func (s *agentService) UpdateStatus(ctx context.Context, id string, status AgentStatus) error {
-	s.logger.Info("Updating agent status",
-		zap.String("id", id),
-		zap.String("status", string(status)),
-	)
-	
-	// TODO: Implement gRPC call
-	
-	return nil
-}
-
-// UpdateConfidence updates an agent's confidence score
-func (s *agentService) UpdateConfidence(ctx context.Context, id string, confidence float64) error {
-	s.logger.Debug("Updating agent confidence",
-		zap.String("id", id),
-		zap.Float64("confidence", confidence),
-	)
-	
-	if confidence < 0 || confidence > 1 {
-		return fmt.Errorf("confidence must be between 0 and 1")
-	}
-	
-	// TODO: Implement gRPC call
-	
-	return nil
-}
-
-// Heartbeat sends a heartbeat for an agent
-func (s *agentService) Heartbeat(ctx context.Context, id string) error {
-	s.logger.Debug("Sending heartbeat", zap.String("id", id))
-	
-	// TODO: Implement gRPC call
-	
-	return nil
-}
-
-// Unregister removes an agent from the system
-func (s *agentService) Unregister(ctx context.Context, id string) error {
-	s.logger.Info("Unregistering agent", zap.String("id", id))
-	
-	// TODO: Implement gRPC call
-	
-	return nil
-}
-
-// StreamAgents streams agent updates
-func (s *agentService) StreamAgents(ctx context.Context) (<-chan *AgentInfo, error) {
-	s.logger.Debug("Streaming agents")
-	
-	// TODO: Implement gRPC streaming
-	
-	// Mock implementation
-	ch := make(chan *AgentInfo)
-	
-	go func() {
-		defer close(ch)
-		
-		// Send initial agents
-		agents, _ := s.List(ctx)
-		for _, agent := range agents {
-			select {
-			case ch <- agent:
-			case <-ctx.Done():
-				return
-			}
-		}
-		
-		// Stream updates
-		ticker := timeTicker(10 * timeSecond)
-		defer ticker.Stop()
-		
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				// Simulate agent status updates
-				agent := &AgentInfo{
-					ID:           fmt.Sprintf("agent-%d", timeNow().Unix()%100),
-					Name:         "Dynamic Agent",
-					Status:       AgentStatusActive,
-					Capabilities: []string{"dynamic"},
-					Endpoint:     "localhost:50099",
-					LastSeen:     timeNow(),
-					Confidence:   0.5 + (float64(timeNow().Unix()%50) / 100),
-				}
-				
-				select {
-				case ch <- agent:
-				case <-ctx.Done():
-					return
-				}
-			}
-		}
-	}()
-	
-	return ch, nil
-}
\ No newline at end of file
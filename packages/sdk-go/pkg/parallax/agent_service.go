@@ -13,6 +13,19 @@ type agentService struct {
 	client *Client
 	logger *zap.Logger
 	leases map[string]string
+	agents map[string]*AgentInfo
+
+	// policyEvals, when non-nil, supplies StreamAgents with the
+	// PolicyEvaluationSummary to attach to each AgentInfo it emits. Set
+	// from ClientConfig.PolicyEvaluations.
+	policyEvals *PolicyEvaluationTracker
+}
+
+// registryClient returns a RegistryServiceClient bound to the client's
+// clientset, so every call below shares the clientset's pooled connection
+// and picks up its interceptors/mocks instead of re-wrapping s.client.conn.
+func (s *agentService) registryClient() (RegistryServiceClient, error) {
+	return s.client.clientSet.RegistryClient(s.client.endpoint)
 }
 
 // Register registers a new agent
@@ -22,19 +35,19 @@ func (s *agentService) Register(ctx context.Context, agent *AgentInfo) error {
 		zap.String("name", agent.Name),
 		zap.Strings("capabilities", agent.Capabilities),
 	)
-	
+
 	if agent.ID == "" {
 		agent.ID = generateID()
 	}
-	
+
 	if agent.Status == "" {
 		agent.Status = AgentStatusActive
 	}
-	
+
 	if agent.Confidence == 0 {
 		agent.Confidence = 0.8
 	}
-	
+
 	agent.LastSeen = timeNow()
 
 	labels := map[string]string{}
@@ -42,6 +55,14 @@ func (s *agentService) Register(ctx context.Context, agent *AgentInfo) error {
 		labels[key] = value
 	}
 
+	if len(agent.Policy) > 0 {
+		encoded, err := agent.Policy.toYAML()
+		if err != nil {
+			return fmt.Errorf("encode confidence policy: %w", err)
+		}
+		labels[PolicyMetadataKey] = encoded
+	}
+
 	registration := &generated.AgentRegistration{
 		Id:           agent.ID,
 		Name:         agent.Name,
@@ -55,7 +76,11 @@ func (s *agentService) Register(ctx context.Context, agent *AgentInfo) error {
 		},
 	}
 
-	client := generated.NewRegistryClient(s.client.conn)
+	client, err := s.registryClient()
+	if err != nil {
+		return err
+	}
+
 	response, err := client.Register(ctx, &generated.RegisterRequest{
 		Agent:     registration,
 		AutoRenew: true,
@@ -66,6 +91,15 @@ func (s *agentService) Register(ctx context.Context, agent *AgentInfo) error {
 
 	if response.LeaseId != "" {
 		s.leases[agent.ID] = response.LeaseId
+		s.agents[agent.ID] = agent
+	}
+
+	if s.client.kvClient != nil {
+		if err := s.client.kvClient.CAS(ctx, kvAgentsPrefix+agent.ID, func(interface{}) (interface{}, bool, error) {
+			return agent, false, nil
+		}); err != nil {
+			s.logger.Warn("Failed to publish agent to kv store", zap.String("id", agent.ID), zap.Error(err))
+		}
 	}
 
 	return nil
@@ -75,7 +109,11 @@ func (s *agentService) Register(ctx context.Context, agent *AgentInfo) error {
 func (s *agentService) List(ctx context.Context) ([]*AgentInfo, error) {
 	s.logger.Debug("Listing agents")
 
-	client := generated.NewRegistryClient(s.client.conn)
+	client, err := s.registryClient()
+	if err != nil {
+		return nil, err
+	}
+
 	response, err := client.ListAgents(ctx, &generated.ListAgentsRequest{})
 	if err != nil {
 		return nil, err
@@ -93,7 +131,11 @@ func (s *agentService) List(ctx context.Context) ([]*AgentInfo, error) {
 func (s *agentService) Get(ctx context.Context, id string) (*AgentInfo, error) {
 	s.logger.Debug("Getting agent", zap.String("id", id))
 
-	client := generated.NewRegistryClient(s.client.conn)
+	client, err := s.registryClient()
+	if err != nil {
+		return nil, err
+	}
+
 	response, err := client.GetAgent(ctx, &generated.GetAgentRequest{AgentId: id})
 	if err != nil {
 		return nil, err
@@ -118,7 +160,7 @@ func (s *agentService) UpdateConfidence(ctx context.Context, id string, confiden
 		zap.String("id", id),
 		zap.Float64("confidence", confidence),
 	)
-	
+
 	if confidence < 0 || confidence > 1 {
 		return fmt.Errorf("confidence must be between 0 and 1")
 	}
@@ -126,28 +168,59 @@ func (s *agentService) UpdateConfidence(ctx context.Context, id string, confiden
 	return fmt.Errorf("update confidence is not supported by the registry API")
 }
 
-// Heartbeat sends a heartbeat for an agent
+// Heartbeat sends a heartbeat for an agent. Unlike a plain Renew, a missing
+// or expired lease is recoverable: the agent's last known registration is
+// replayed through Register so a restarted or rescheduled control plane
+// doesn't permanently strand the caller.
 func (s *agentService) Heartbeat(ctx context.Context, id string) error {
 	s.logger.Debug("Sending heartbeat", zap.String("id", id))
 
 	leaseId, ok := s.leases[id]
 	if !ok {
-		return fmt.Errorf("no lease found for agent %s", id)
+		return s.reregister(ctx, id)
 	}
 
-	client := generated.NewRegistryClient(s.client.conn)
-	_, err := client.Renew(ctx, &generated.RenewRequest{LeaseId: leaseId})
-	return err
+	client, err := s.registryClient()
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.Renew(ctx, &generated.RenewRequest{LeaseId: leaseId}); err != nil {
+		s.logger.Warn("Lease renewal failed, attempting re-registration",
+			zap.String("id", id), zap.Error(err))
+		delete(s.leases, id)
+		return s.reregister(ctx, id)
+	}
+	return nil
+}
+
+// reregister replays the last known AgentInfo for id through Register,
+// recovering from a lease the control plane no longer recognizes.
+func (s *agentService) reregister(ctx context.Context, id string) error {
+	agent, ok := s.agents[id]
+	if !ok {
+		return fmt.Errorf("no lease or known registration for agent %s", id)
+	}
+	return s.Register(ctx, agent)
 }
 
 // Unregister removes an agent from the system
 func (s *agentService) Unregister(ctx context.Context, id string) error {
 	s.logger.Info("Unregistering agent", zap.String("id", id))
 
-	client := generated.NewRegistryClient(s.client.conn)
-	_, err := client.Unregister(ctx, &generated.AgentRegistration{Id: id})
+	client, err := s.registryClient()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Unregister(ctx, &generated.AgentRegistration{Id: id})
 	if err == nil {
 		delete(s.leases, id)
+		if s.client.kvClient != nil {
+			if err := s.client.kvClient.Delete(ctx, kvAgentsPrefix+id); err != nil {
+				s.logger.Warn("Failed to remove agent from kv store", zap.String("id", id), zap.Error(err))
+			}
+		}
 	}
 	return err
 }
@@ -156,7 +229,11 @@ func (s *agentService) Unregister(ctx context.Context, id string) error {
 func (s *agentService) StreamAgents(ctx context.Context) (<-chan *AgentInfo, error) {
 	s.logger.Debug("Streaming agents")
 
-	client := generated.NewRegistryClient(s.client.conn)
+	client, err := s.registryClient()
+	if err != nil {
+		return nil, err
+	}
+
 	stream, err := client.Watch(ctx, &generated.WatchRequest{
 		IncludeInitial: true,
 	})
@@ -176,8 +253,15 @@ func (s *agentService) StreamAgents(ctx context.Context) (<-chan *AgentInfo, err
 				continue
 			}
 
+			info := agentFromRegistration(event.Agent)
+			if s.policyEvals != nil {
+				if summary, ok := s.policyEvals.Summary(info.ID); ok {
+					info.PolicyEvaluation = &summary
+				}
+			}
+
 			select {
-			case ch <- agentFromRegistration(event.Agent):
+			case ch <- info:
 			case <-ctx.Done():
 				return
 			}
@@ -211,6 +295,13 @@ func agentFromRegistration(agent *generated.AgentRegistration) *AgentInfo {
 		defaultConfidence = agent.Metadata.GetDefaultConfidence()
 	}
 
+	var policy ConfidencePolicy
+	if raw, ok := metadata[PolicyMetadataKey]; ok {
+		if parsed, err := PolicyFromYAML([]byte(raw)); err == nil {
+			policy = parsed
+		}
+	}
+
 	return &AgentInfo{
 		ID:           agent.GetId(),
 		Name:         agent.GetName(),
@@ -220,5 +311,6 @@ func agentFromRegistration(agent *generated.AgentRegistration) *AgentInfo {
 		LastSeen:     lastSeen,
 		Confidence:   defaultConfidence,
 		Metadata:     metadata,
+		Policy:       policy,
 	}
 }
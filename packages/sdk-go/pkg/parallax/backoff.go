@@ -0,0 +1,106 @@
+package parallax
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffConfig tunes the exponential-backoff-with-jitter delay GrpcAgent
+// uses when retrying registration, re-registration after a failed lease
+// renewal, and transparently retrying idempotent Analyze calls (see
+// WithBackoff, WithRetryPolicy). The delay for 0-based attempt n is
+// min(MaxDelay, BaseDelay*Multiplier^n), jittered by +/-Jitter of itself.
+type BackoffConfig struct {
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// DefaultBackoffConfig is the backoff NewGrpcAgent starts from absent a
+// WithBackoff option: 1s base, 120s max, 1.6x multiplier, +/-20% jitter.
+func DefaultBackoffConfig() BackoffConfig {
+	return BackoffConfig{
+		BaseDelay:  1 * time.Second,
+		MaxDelay:   120 * time.Second,
+		Multiplier: 1.6,
+		Jitter:     0.2,
+	}
+}
+
+// delay returns the jittered backoff for 0-based attempt.
+func (c BackoffConfig) delay(attempt int) time.Duration {
+	d := float64(c.BaseDelay) * math.Pow(c.Multiplier, float64(attempt))
+	if max := float64(c.MaxDelay); max > 0 && d > max {
+		d = max
+	}
+	if c.Jitter > 0 {
+		d *= 1 + rand.Float64()*2*c.Jitter - c.Jitter
+	}
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+// PerformedIOError wraps an error Agent.Analyze returns to signal that the
+// agent already performed side-effecting I/O (a write, an external call,
+// ...) before failing, so the same task must NOT be retried even though
+// the underlying error might otherwise look transient. GrpcAgent.Analyze
+// surfaces a PerformedIOError as codes.Internal and every other error as
+// codes.Unavailable, and AnalyzeRetryPolicy never retries one.
+type PerformedIOError struct {
+	Err error
+}
+
+// Error implements error.
+func (e *PerformedIOError) Error() string { return e.Err.Error() }
+
+// Unwrap lets errors.As/errors.Is see through to the underlying cause.
+func (e *PerformedIOError) Unwrap() error { return e.Err }
+
+// WrapPerformedIO marks err as resulting from an Analyze call that already
+// performed side-effecting I/O. Agents call this from their own Analyze
+// implementation before returning an error that followed a partial write
+// or external side effect. Returns nil if err is nil.
+func WrapPerformedIO(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PerformedIOError{Err: err}
+}
+
+// AnalyzeRetryPolicy configures GrpcAgent's embedded transparent retry for
+// Analyze calls, installed via WithRetryPolicy. IsIdempotent reports
+// whether task is safe to retry in-process after a failure; a nil
+// IsIdempotent (the zero value) retries nothing, matching GrpcAgent's
+// behavior before WithRetryPolicy existed.
+type AnalyzeRetryPolicy struct {
+	// IsIdempotent reports whether task may be retried without risking a
+	// duplicate side effect. Called once per Analyze call, before the
+	// first attempt.
+	IsIdempotent func(task string) bool
+
+	// MaxAttempts caps attempts per call, including the first. Zero means
+	// one attempt, i.e. no retries even if IsIdempotent is set.
+	MaxAttempts int
+
+	// Backoff bounds the delay between retries. The zero value falls back
+	// to DefaultBackoffConfig.
+	Backoff BackoffConfig
+}
+
+// backoff returns p.Backoff, falling back to DefaultBackoffConfig when it
+// was left at its zero value.
+func (p AnalyzeRetryPolicy) backoff() BackoffConfig {
+	if p.Backoff == (BackoffConfig{}) {
+		return DefaultBackoffConfig()
+	}
+	return p.Backoff
+}
+
+// retryable reports whether task may be retried under p.
+func (p AnalyzeRetryPolicy) retryable(task string) bool {
+	return p.IsIdempotent != nil && p.IsIdempotent(task)
+}
@@ -5,15 +5,17 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -21,6 +23,28 @@ import (
 	"parallax/sdk-go/generated"
 )
 
+// LeaseEventType describes a transition observed on the lease keepalive stream.
+type LeaseEventType string
+
+const (
+	// LeaseRenewed is emitted whenever the registry acknowledges a keepalive.
+	LeaseRenewed LeaseEventType = "RENEWED"
+	// LeaseLost is emitted when the keepalive stream breaks and the lease
+	// could not be recovered before the retry budget gave up on this attempt.
+	LeaseLost LeaseEventType = "LOST"
+	// LeaseReregistered is emitted after a broken stream forced a fresh
+	// Register call and a new lease was obtained.
+	LeaseReregistered LeaseEventType = "REREGISTERED"
+)
+
+// LeaseEvent reports a lease keepalive state transition for observers of
+// ParallaxAgent.LeaseKeepAliveCh.
+type LeaseEvent struct {
+	Type    LeaseEventType
+	LeaseID string
+	Err     error
+}
+
 // AgentResult represents the result of an agent's analysis
 type AgentResult struct {
 	Value         interface{}
@@ -28,6 +52,12 @@ type AgentResult struct {
 	Reasoning     string
 	Uncertainties []string
 	Metadata      map[string]string
+
+	// AgentID identifies which agent produced this result. Populated when
+	// the result comes from a peer agent's Analyze/StreamAnalyze response
+	// (see agentResultFromConfidence); empty for results an AnalyzeFunc
+	// returns directly, since the caller already knows its own agent ID.
+	AgentID string
 }
 
 // ParallaxAgent is the base class for all Parallax agents in Go
@@ -36,62 +66,229 @@ type ParallaxAgent struct {
 	Name         string
 	Capabilities []string
 	Metadata     map[string]interface{}
-	
+
+	// Metrics, when set, registers the SDK's gRPC/lease/confidence
+	// collectors on this registry and has Serve start a /metrics HTTP
+	// handler on MetricsAddr. Leave nil to disable instrumentation.
+	Metrics *prometheus.Registry
+
+	// MetricsAddr is the listen address for the embedded /metrics
+	// handler. Defaults to ":9090" when Metrics is set and MetricsAddr is
+	// empty. Users running their own HTTP mux can ignore both fields and
+	// mount NewMetrics(registry).Handler() directly instead.
+	MetricsAddr string
+
+	// ServerOptions installs additional gRPC interceptors (see
+	// WithRecovery, WithAuth, WithMetrics) on the agent's gRPC server,
+	// alongside the SDK's own otelgrpc/*Metrics instrumentation. Set via
+	// WithServerOptions.
+	ServerOptions []ServerOption
+
 	server       *grpc.Server
 	port         int
 	registryAddr string
+	clientSet    *RegistryClientSet
+	retryPolicy  *RetryPolicy
 	leaseID      string
-	renewStop    chan bool
+	leaseTTL     time.Duration
+	leaseEvents  chan LeaseEvent
+	metrics      *Metrics
 	mu           sync.Mutex
-	
+
+	supervisor *Supervisor
+	cancel     context.CancelFunc
+
 	// Abstract method that must be implemented by subclasses
 	AnalyzeFunc func(ctx context.Context, task string, data interface{}) (*AgentResult, error)
+
+	// StreamAnalyzeFunc, when set, lets implementations push interim
+	// results (partial reasoning, rising confidence, tool-use traces) as
+	// they compute instead of returning a single result. StreamAnalyze
+	// falls back to one-shot AnalyzeFunc behavior when this is nil.
+	StreamAnalyzeFunc func(ctx context.Context, task string, data interface{}, emit func(*AgentResult) error) error
+}
+
+// ParallaxAgentOption configures optional ParallaxAgent behavior at
+// construction time.
+type ParallaxAgentOption func(*ParallaxAgent)
+
+// WithRegistryClientSet injects a RegistryClientSet, letting callers supply
+// custom dial options (TLS creds, keepalive params, interceptors) or a mock
+// clientset for tests instead of the default insecure, pooled clientset
+// wrapped in the retry policy's interceptors.
+func WithRegistryClientSet(cs *RegistryClientSet) ParallaxAgentOption {
+	return func(a *ParallaxAgent) {
+		a.clientSet = cs
+	}
+}
+
+// WithRetryPolicy configures the retry-with-backoff and circuit-breaker
+// policy wrapped around the agent's default RegistryClientSet, so Register
+// and the lease-renewer's KeepAlive stream survive transient control-plane
+// errors (network blips, rolling restarts) instead of failing hard. It has
+// no effect when combined with WithRegistryClientSet, which takes full
+// control of dialing. Nil (or omitting this option) falls back to
+// DefaultRetryPolicy.
+func WithRetryPolicy(policy *RetryPolicy) ParallaxAgentOption {
+	return func(a *ParallaxAgent) {
+		a.retryPolicy = policy
+	}
+}
+
+// WithServerOptions installs additional gRPC interceptors (see
+// WithRecovery, WithAuth, WithMetrics) on the agent's gRPC server,
+// alongside the SDK's own otelgrpc/*Metrics instrumentation.
+func WithServerOptions(opts ...ServerOption) ParallaxAgentOption {
+	return func(a *ParallaxAgent) {
+		a.ServerOptions = append(a.ServerOptions, opts...)
+	}
+}
+
+// LeaseKeepAliveCh returns a channel of lease keepalive transitions
+// (RENEWED / LOST / REREGISTERED) observed on the bidi keepalive stream.
+// The channel is created lazily and closed when the agent shuts down.
+func (a *ParallaxAgent) LeaseKeepAliveCh() <-chan LeaseEvent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.leaseEvents == nil {
+		a.leaseEvents = make(chan LeaseEvent, 16)
+	}
+	return a.leaseEvents
+}
+
+func (a *ParallaxAgent) emitLeaseEvent(evt LeaseEvent) {
+	a.metrics.recordLeaseRenewal(evt.Type)
+
+	a.mu.Lock()
+	ch := a.leaseEvents
+	a.mu.Unlock()
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- evt:
+	default:
+		// Drop rather than block the keepalive loop on a slow consumer.
+	}
 }
 
 // NewParallaxAgent creates a new ParallaxAgent
-func NewParallaxAgent(id, name string, capabilities []string, metadata map[string]interface{}) *ParallaxAgent {
-	return &ParallaxAgent{
+func NewParallaxAgent(id, name string, capabilities []string, metadata map[string]interface{}, opts ...ParallaxAgentOption) *ParallaxAgent {
+	a := &ParallaxAgent{
 		ID:           id,
 		Name:         name,
 		Capabilities: capabilities,
 		Metadata:     metadata,
 		registryAddr: getEnvOrDefault("PARALLAX_REGISTRY", "localhost:50051"),
-		renewStop:    make(chan bool),
 	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.clientSet == nil {
+		retry := a.retryPolicy
+		if retry == nil {
+			retry = DefaultRetryPolicy()
+		}
+		a.clientSet = NewRegistryClientSet(ClientSetOptions{
+			UnaryInterceptors:  []grpc.UnaryClientInterceptor{retry.UnaryClientInterceptor()},
+			StreamInterceptors: []grpc.StreamClientInterceptor{retry.StreamClientInterceptor()},
+		})
+	}
+
+	return a
 }
 
-// Serve starts the gRPC server and registers with the control plane
+// Serve starts the gRPC server and registers with the control plane. It
+// registers the gRPC server and the lease-renewer as named services under
+// one Supervisor and blocks until Shutdown cancels the root context and
+// every service has returned.
 func (a *ParallaxAgent) Serve(port int) error {
-	a.server = grpc.NewServer()
+	if a.Metrics != nil {
+		a.metrics = NewMetrics(a.Metrics)
+	}
+
+	serverOpts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(a.metrics.unaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(a.metrics.streamServerInterceptor()),
+	}
+	serverOpts = append(serverOpts, chainServerOptions(a.ServerOptions)...)
+	a.server = grpc.NewServer(serverOpts...)
 	a.port = port
-	
+
 	// Register the ConfidenceAgent service
 	generated.RegisterConfidenceAgentServer(a.server, a)
-	
+
 	// Start listening
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return fmt.Errorf("failed to listen: %w", err)
 	}
-	
+
 	// Get the actual port if 0 was specified
 	if port == 0 {
 		a.port = lis.Addr().(*net.TCPAddr).Port
 	}
-	
+
 	log.Printf("Agent %s (%s) listening on port %d", a.Name, a.ID, a.port)
-	
-	// Register with control plane
-	if err := a.register(); err != nil {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+
+	// Register with control plane. A failed initial registration is not
+	// fatal: the lease-renewer service retries it on its own schedule.
+	if err := a.register(ctx); err != nil {
 		log.Printf("Failed to register with control plane: %v", err)
-		// Continue anyway - agent can work without registration
 	}
-	
+
+	a.supervisor = NewSupervisor()
+	a.supervisor.Add(ServiceFunc{ServiceName: "grpc-server", Func: func(ctx context.Context) error {
+		go func() {
+			<-ctx.Done()
+			a.server.GracefulStop()
+		}()
+		return a.server.Serve(lis)
+	}})
+	a.supervisor.Add(ServiceFunc{ServiceName: "lease-renewer", Func: a.runLeaseRenewer})
+
+	if a.metrics != nil {
+		a.supervisor.Add(ServiceFunc{ServiceName: "metrics-server", Func: a.serveMetrics})
+	}
+
 	// Handle graceful shutdown
 	go a.handleShutdown()
-	
-	// Start serving
-	return a.server.Serve(lis)
+
+	a.supervisor.Serve(ctx)
+	return a.supervisor.Err()
+}
+
+// serveMetrics runs the embedded /metrics HTTP handler until ctx is
+// canceled. It is only added to the Supervisor when Metrics is set; users
+// running their own HTTP mux should ignore it and mount
+// NewMetrics(registry).Handler() on their own server instead.
+func (a *ParallaxAgent) serveMetrics(ctx context.Context) error {
+	addr := a.MetricsAddr
+	if addr == "" {
+		addr = ":9090"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", a.metrics.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
 }
 
 // Analyze implements the gRPC ConfidenceAgent.Analyze method
@@ -115,7 +312,9 @@ func (a *ParallaxAgent) Analyze(ctx context.Context, req *generated.AgentRequest
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "analysis failed: %v", err)
 	}
-	
+
+	a.metrics.setAgentConfidence(a.ID, result.Confidence)
+
 	// Convert to protobuf response
 	return &generated.ConfidenceResult{
 		ValueJson:     mustMarshalJSON(result.Value),
@@ -128,14 +327,50 @@ func (a *ParallaxAgent) Analyze(ctx context.Context, req *generated.AgentRequest
 	}, nil
 }
 
-// StreamAnalyze implements the gRPC ConfidenceAgent.StreamAnalyze method
+// StreamAnalyze implements the gRPC ConfidenceAgent.StreamAnalyze method. If
+// StreamAnalyzeFunc is set, it invokes it and forwards each emitted
+// AgentResult as it arrives, stopping as soon as the client drops the
+// stream. Agents that only implement AnalyzeFunc keep the one-shot
+// single-send behavior.
 func (a *ParallaxAgent) StreamAnalyze(req *generated.AgentRequest, stream grpc.ServerStreamingServer[generated.ConfidenceResult]) error {
-	// For now, just analyze once and send result
-	resp, err := a.Analyze(stream.Context(), req)
-	if err != nil {
-		return err
+	if a.StreamAnalyzeFunc == nil {
+		resp, err := a.Analyze(stream.Context(), req)
+		if err != nil {
+			return err
+		}
+		return stream.Send(resp)
+	}
+
+	if req.TaskDescription == "" {
+		return status.Error(codes.InvalidArgument, "task description is required")
 	}
-	return stream.Send(resp)
+
+	var data interface{}
+	if req.Data != nil {
+		data = req.Data.AsMap()
+	}
+
+	ctx := stream.Context()
+	emit := func(result *AgentResult) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		a.metrics.setAgentConfidence(a.ID, result.Confidence)
+		return stream.Send(&generated.ConfidenceResult{
+			ValueJson:     mustMarshalJSON(result.Value),
+			Confidence:    result.Confidence,
+			AgentId:       a.ID,
+			Timestamp:     timestamppb.Now(),
+			Reasoning:     result.Reasoning,
+			Uncertainties: result.Uncertainties,
+			Metadata:      result.Metadata,
+		})
+	}
+
+	if err := a.StreamAnalyzeFunc(ctx, req.TaskDescription, data, emit); err != nil {
+		return status.Errorf(codes.Internal, "streaming analysis failed: %v", err)
+	}
+	return nil
 }
 
 // GetCapabilities implements the gRPC ConfidenceAgent.GetCapabilities method
@@ -153,19 +388,25 @@ func (a *ParallaxAgent) HealthCheck(ctx context.Context, req *emptypb.Empty) (*g
 	}, nil
 }
 
-// register registers the agent with the control plane
-func (a *ParallaxAgent) register() error {
-	conn, err := grpc.NewClient(a.registryAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// registryClient returns a RegistryServiceClient bound to the agent's
+// clientset, which lazily dials and ref-counts one connection per endpoint
+// instead of opening a fresh connection per call.
+func (a *ParallaxAgent) registryClient() (RegistryServiceClient, error) {
+	return a.clientSet.RegistryClient(a.registryAddr)
+}
+
+// register registers the agent with the control plane, recording the
+// returned lease ID and TTL. It does not itself start the keepalive
+// stream; the lease-renewer service owns that once register succeeds.
+func (a *ParallaxAgent) register(ctx context.Context) error {
+	client, err := a.registryClient()
 	if err != nil {
-		return fmt.Errorf("failed to connect to registry: %w", err)
+		return err
 	}
-	defer conn.Close()
-	
-	client := generated.NewRegistryClient(conn)
-	
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+	rctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
-	
+
 	req := &generated.RegisterRequest{
 		Agent: &generated.AgentRegistration{
 			Id:           a.ID,
@@ -174,108 +415,161 @@ func (a *ParallaxAgent) register() error {
 			Capabilities: a.Capabilities,
 		},
 	}
-	
-	resp, err := client.Register(ctx, req)
+
+	resp, err := client.Register(rctx, req)
 	if err != nil {
 		return fmt.Errorf("failed to register: %w", err)
 	}
-	
+
+	ttl := resp.TtlSeconds
+	if ttl <= 0 {
+		ttl = 30
+	}
+
 	a.mu.Lock()
 	a.leaseID = resp.LeaseId
+	a.leaseTTL = time.Duration(ttl) * time.Second
 	a.mu.Unlock()
-	
+
+	a.metrics.setLeaseTTL(a.ID, a.leaseTTL.Seconds())
 	log.Printf("Agent %s registered with control plane, lease_id: %s", a.ID, a.leaseID)
-	
-	// Start lease renewal
-	go a.renewLease()
-	
 	return nil
 }
 
-// renewLease periodically renews the agent's lease
-func (a *ParallaxAgent) renewLease() {
-	ticker := time.NewTicker(30 * time.Second)
+// runLeaseRenewer is the lease-renewer Service. It (re-)registers if the
+// agent doesn't currently hold a lease, then keeps it alive over a single
+// long-lived bidi KeepAlive stream, pushing a request at ttl/3 and reading
+// acks in the background. Returning an error tells the Supervisor to
+// restart it with backoff; the next run re-registers because a stream error
+// from runKeepAliveStream makes it clear the lease ID before returning.
+func (a *ParallaxAgent) runLeaseRenewer(ctx context.Context) error {
+	reregistering := a.currentLeaseID() == ""
+	if reregistering {
+		if err := a.register(ctx); err != nil {
+			return fmt.Errorf("register: %w", err)
+		}
+		a.emitLeaseEvent(LeaseEvent{Type: LeaseReregistered, LeaseID: a.currentLeaseID()})
+	}
+
+	client, err := a.registryClient()
+	if err != nil {
+		a.emitLeaseEvent(LeaseEvent{Type: LeaseLost, Err: err})
+		return err
+	}
+
+	stream, err := client.KeepAlive(ctx)
+	if err != nil {
+		a.emitLeaseEvent(LeaseEvent{Type: LeaseLost, Err: err})
+		return err
+	}
+
+	interval := a.currentTTL() / 3
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	if err := a.runKeepAliveStream(ctx, stream, interval); err != nil {
+		a.emitLeaseEvent(LeaseEvent{Type: LeaseLost, Err: err})
+		a.mu.Lock()
+		a.leaseID = ""
+		a.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// runKeepAliveStream pushes a KeepAliveRequest every interval and reads acks
+// until the stream breaks or ctx is canceled.
+func (a *ParallaxAgent) runKeepAliveStream(ctx context.Context, stream generated.Registry_KeepAliveClient, interval time.Duration) error {
+	ackErrCh := make(chan error, 1)
+	go func() {
+		for {
+			ack, err := stream.Recv()
+			if err != nil {
+				ackErrCh <- err
+				return
+			}
+			a.emitLeaseEvent(LeaseEvent{Type: LeaseRenewed, LeaseID: ack.LeaseId})
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
-	
+
 	for {
 		select {
+		case <-ctx.Done():
+			_ = stream.CloseSend()
+			return nil
+		case err := <-ackErrCh:
+			return err
 		case <-ticker.C:
-			a.mu.Lock()
-			leaseID := a.leaseID
-			a.mu.Unlock()
-			
-			if leaseID == "" {
-				continue
-			}
-			
-			conn, err := grpc.NewClient(a.registryAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-			if err != nil {
-				log.Printf("Failed to connect for lease renewal: %v", err)
-				continue
-			}
-			
-			client := generated.NewRegistryClient(conn)
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			
-			resp, err := client.Renew(ctx, &generated.RenewRequest{LeaseId: leaseID})
-			conn.Close()
-			cancel()
-			
-			if err != nil {
-				log.Printf("Failed to renew lease: %v", err)
-				continue
+			if err := stream.Send(&generated.KeepAliveRequest{LeaseId: a.currentLeaseID()}); err != nil {
+				return err
 			}
-			
-			if !resp.Success {
-				log.Printf("Lease renewal failed")
-				// Try to re-register
-				if err := a.register(); err != nil {
-					log.Printf("Failed to re-register: %v", err)
-				}
-			}
-		case <-a.renewStop:
-			return
 		}
 	}
 }
 
-// Shutdown gracefully shuts down the agent
+func (a *ParallaxAgent) currentLeaseID() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.leaseID
+}
+
+func (a *ParallaxAgent) currentTTL() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.leaseTTL
+}
+
+// Shutdown gracefully shuts down the agent. It cancels the root context
+// shared by every service registered in Serve's Supervisor and waits for
+// the gRPC server and lease-renewer to return before releasing the
+// registry connections. Embedders control process lifetime: Shutdown
+// never calls os.Exit.
 func (a *ParallaxAgent) Shutdown() error {
-	// Stop lease renewal
-	close(a.renewStop)
-	
-	// Unregister from control plane
-	if a.leaseID != "" {
-		conn, err := grpc.NewClient(a.registryAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-		if err == nil {
-			client := generated.NewRegistryClient(conn)
+	// Unregister from control plane while the shared connection is still
+	// open, before canceling the context tears it down.
+	if a.currentLeaseID() != "" {
+		if client, err := a.registryClient(); err == nil {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			
 			_, _ = client.Unregister(ctx, &generated.AgentRegistration{Id: a.ID})
-			
 			cancel()
-			conn.Close()
 		}
 	}
-	
-	// Stop gRPC server
-	if a.server != nil {
-		a.server.GracefulStop()
+
+	if a.cancel != nil {
+		a.cancel()
 	}
-	
+	if a.supervisor != nil {
+		a.supervisor.Wait()
+	}
+
+	_ = a.clientSet.Close()
+
+	a.mu.Lock()
+	if a.leaseEvents != nil {
+		close(a.leaseEvents)
+		a.leaseEvents = nil
+	}
+	a.mu.Unlock()
+
 	log.Printf("Agent %s shut down", a.ID)
 	return nil
 }
 
-// handleShutdown handles graceful shutdown on signals
+// handleShutdown waits for an interrupt or SIGTERM and shuts the agent
+// down gracefully. It does not terminate the process; callers that want
+// that run handleShutdown's signal themselves or wrap Serve accordingly.
 func (a *ParallaxAgent) handleShutdown() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	
+
 	<-sigChan
 	log.Println("Shutting down agent...")
 	a.Shutdown()
-	os.Exit(0)
 }
 
 // Helper function to get environment variable with default
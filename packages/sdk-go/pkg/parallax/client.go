@@ -5,38 +5,84 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+
+	"parallax/sdk-go/pkg/parallax/kv"
+	"parallax/sdk-go/pkg/parallax/ring"
 )
 
 // Client represents a Parallax control plane client
 type Client struct {
 	endpoint   string
 	conn       *grpc.ClientConn
+	clientSet  *RegistryClientSet
 	logger     *zap.Logger
+	metrics    *Metrics
+	tlsCreds   *tlsCredentials
 	patternSvc PatternService
 	agentSvc   AgentService
+
+	// kvClient and ring are non-nil only when ClientConfig.KVStore was
+	// configured. agentService writes agent liveness through kvClient so
+	// every control-plane replica's ring stays in sync via WatchPrefix.
+	kvClient kv.Client
+	ring     *ring.Ring
+	kvCancel context.CancelFunc
 }
 
 // ClientConfig holds configuration for the Parallax client
 type ClientConfig struct {
-	Endpoint        string
-	Logger          *zap.Logger
-	MaxRetries      int
-	RequestTimeout  time.Duration
-	KeepAlive       time.Duration
-	ConnectTimeout  time.Duration
-	TLSConfig       *TLSConfig
-}
+	Endpoint       string
+	Logger         *zap.Logger
+	RequestTimeout time.Duration
+	KeepAlive      time.Duration
+	ConnectTimeout time.Duration
+	TLSConfig      *TLSConfig
+
+	// Metrics, when set, registers the SDK's gRPC/pattern collectors on
+	// this registry and has every outbound RPC recorded against it. Share
+	// the same registry passed to a ParallaxAgent's Metrics field to get
+	// one combined /metrics endpoint for a process running both.
+	Metrics *prometheus.Registry
+
+	// Retry configures retry-with-backoff and the circuit breaker wrapped
+	// around every control-plane RPC this client issues. Nil falls back to
+	// DefaultRetryPolicy; pass &RetryPolicy{MaxAttempts: 1} to disable
+	// retries outright.
+	Retry *RetryPolicy
+
+	// ClientSet, when set, supplies the pooled connection (and any dial
+	// options, interceptors, or mock RegistryServiceClient) NewClient uses
+	// instead of building a default one. Tests inject a clientset backed by
+	// a generated mock here instead of dialing a real registry.
+	ClientSet *RegistryClientSet
+
+	// AuthToken, when set, is attached as a "Bearer" authorization
+	// metadata value on every RPC the default clientset issues (Register,
+	// Heartbeat/Renew, Watch, ...), the client side of
+	// WithAgentTokenAuth/WithAuth. Has no effect when combined with
+	// ClientSet, which takes full control of dialing.
+	AuthToken string
 
-// TLSConfig holds TLS configuration
-type TLSConfig struct {
-	CertFile   string
-	KeyFile    string
-	CAFile     string
-	ServerName string
+	// KVStore, when Store is non-empty, backs a consistent-hash ring of
+	// registered agents kept in sync across every control-plane replica
+	// sharing the store. When set, agentService.Register/Unregister write
+	// agent liveness through it and PatternService.Execute uses the ring
+	// for stable agent selection instead of list-order truncation. Leave
+	// the zero value to keep agent selection purely local to this process.
+	KVStore kv.Config
+
+	// PolicyEvaluations, when set, is attached to every AgentInfo
+	// agentService.StreamAgents emits as AgentInfo.PolicyEvaluation. Share
+	// the same tracker passed to WithConfidencePolicy on each agent
+	// (directly, or via a transport forwarding AuditEvents into it) to
+	// give this client's StreamAgents callers a live view of which scopes
+	// are currently blocking or warning.
+	PolicyEvaluations *PolicyEvaluationTracker
 }
 
 // NewClient creates a new Parallax client
@@ -61,47 +107,87 @@ func NewClient(config ClientConfig) (*Client, error) {
 		config.ConnectTimeout = 10 * time.Second
 	}
 
-	// Set up gRPC connection options
-	dialOpts := []grpc.DialOption{
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:                config.KeepAlive,
-			Timeout:             config.KeepAlive,
-			PermitWithoutStream: true,
-		}),
+	var metrics *Metrics
+	if config.Metrics != nil {
+		metrics = NewMetrics(config.Metrics)
 	}
 
-	// Configure TLS if provided
-	if config.TLSConfig != nil {
-		// TODO: Implement TLS configuration
-		config.Logger.Warn("TLS configuration not yet implemented")
-	} else {
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	retry := config.Retry
+	if retry == nil {
+		retry = DefaultRetryPolicy()
 	}
 
-	// Create connection
-	ctx, cancel := context.WithTimeout(context.Background(), config.ConnectTimeout)
-	defer cancel()
+	var tlsCreds *tlsCredentials
+
+	clientSet := config.ClientSet
+	if clientSet == nil {
+		csOpts := ClientSetOptions{
+			DialOptions: []grpc.DialOption{
+				grpc.WithKeepaliveParams(keepalive.ClientParameters{
+					Time:                config.KeepAlive,
+					Timeout:             config.KeepAlive,
+					PermitWithoutStream: true,
+				}),
+				grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+			},
+			UnaryInterceptors:  []grpc.UnaryClientInterceptor{retry.UnaryClientInterceptor(), metrics.unaryClientInterceptor()},
+			StreamInterceptors: []grpc.StreamClientInterceptor{retry.StreamClientInterceptor()},
+		}
+
+		if config.AuthToken != "" {
+			unaryAuth, streamAuth := WithClientTokenAuth(config.AuthToken)
+			csOpts.UnaryInterceptors = append(csOpts.UnaryInterceptors, unaryAuth)
+			csOpts.StreamInterceptors = append(csOpts.StreamInterceptors, streamAuth)
+		}
+
+		if config.TLSConfig != nil {
+			var err error
+			tlsCreds, err = buildTLSCredentials(config.TLSConfig, config.Logger)
+			if err != nil {
+				return nil, fmt.Errorf("failed to build TLS credentials: %w", err)
+			}
+			csOpts.TransportCredentials = tlsCreds
+		}
 
-	conn, err := grpc.DialContext(ctx, config.Endpoint, dialOpts...)
+		clientSet = NewRegistryClientSet(csOpts)
+	}
+
+	conn, err := clientSet.Conn(config.Endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to control plane: %w", err)
 	}
 
 	client := &Client{
-		endpoint: config.Endpoint,
-		conn:     conn,
-		logger:   config.Logger,
+		endpoint:  config.Endpoint,
+		conn:      conn,
+		clientSet: clientSet,
+		logger:    config.Logger,
+		metrics:   metrics,
+		tlsCreds:  tlsCreds,
 	}
 
-	// Initialize services
-	client.patternSvc = &patternService{
-		client: client,
-		logger: config.Logger.With(zap.String("service", "pattern")),
+	if config.KVStore.Store != "" {
+		kvClient, err := kv.NewClient(config.KVStore, agentInfoCodec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build kv client: %w", err)
+		}
+		client.kvClient = kvClient
+		client.ring = ring.New()
+
+		kvCtx, cancel := context.WithCancel(context.Background())
+		client.kvCancel = cancel
+		go client.syncRing(kvCtx)
 	}
 
+	// Initialize services
+	client.patternSvc = newPatternService(client, config.Logger.With(zap.String("service", "pattern")))
+
 	client.agentSvc = &agentService{
-		client: client,
-		logger: config.Logger.With(zap.String("service", "agent")),
+		client:      client,
+		logger:      config.Logger.With(zap.String("service", "agent")),
+		leases:      make(map[string]string),
+		agents:      make(map[string]*AgentInfo),
+		policyEvals: config.PolicyEvaluations,
 	}
 
 	config.Logger.Info("Parallax client connected",
@@ -113,13 +199,56 @@ func NewClient(config ClientConfig) (*Client, error) {
 
 // Close closes the client connection
 func (c *Client) Close() error {
-	if c.conn != nil {
+	if c.clientSet != nil {
 		c.logger.Info("Closing Parallax client connection")
-		return c.conn.Close()
+		c.clientSet.Release(c.endpoint)
+	}
+	if c.kvCancel != nil {
+		c.kvCancel()
+	}
+	if c.kvClient != nil {
+		if err := c.kvClient.Close(); err != nil {
+			return err
+		}
+	}
+	if c.tlsCreds != nil {
+		return c.tlsCreds.Close()
 	}
 	return nil
 }
 
+// kvAgentsPrefix namespaces the agent liveness entries agentService writes
+// through kvClient, keyed by agent ID, from any other state a future
+// request might want to share over the same store.
+const kvAgentsPrefix = "agents/"
+
+// agentInfoCodec is the kv.Codec used for every AgentInfo value this
+// package reads or writes through a kv.Client.
+var agentInfoCodec = kv.JSONCodec{
+	ID:  "agent-info",
+	New: func() interface{} { return &AgentInfo{} },
+}
+
+// syncRing keeps ring up to date with every agent's capabilities by
+// watching kvAgentsPrefix, so every control-plane replica sharing the same
+// kv store converges on the same stable subset for PatternService.Execute.
+// It blocks until ctx is cancelled; callers run it in its own goroutine.
+func (c *Client) syncRing(ctx context.Context) {
+	c.kvClient.WatchPrefix(ctx, kvAgentsPrefix, func(key string, val interface{}) bool {
+		agentID := key[len(kvAgentsPrefix):]
+		if val == nil {
+			c.ring.RemoveAgent(agentID)
+			return true
+		}
+		agent, ok := val.(*AgentInfo)
+		if !ok {
+			return true
+		}
+		c.ring.AddAgent(agentID, agent.Capabilities)
+		return true
+	})
+}
+
 // Patterns returns the pattern service
 func (c *Client) Patterns() PatternService {
 	return c.patternSvc
@@ -129,9 +258,3 @@ func (c *Client) Patterns() PatternService {
 func (c *Client) Agents() AgentService {
 	return c.agentSvc
 }
-
-// HealthCheck checks if the control plane is healthy
-func (c *Client) HealthCheck(ctx context.Context) error {
-	// TODO: Implement health check using gRPC health protocol
-	return nil
-}
\ No newline at end of file
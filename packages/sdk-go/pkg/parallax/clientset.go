@@ -0,0 +1,183 @@
+package parallax
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"parallax/sdk-go/generated"
+)
+
+// RegistryServiceClient is the subset of generated.RegistryClient the SDK
+// depends on. It exists so tests can supply a generated mock instead of
+// dialing a real registry.
+type RegistryServiceClient = generated.RegistryClient
+
+// ConfidenceAgentClient is the subset of generated.ConfidenceAgentClient the
+// SDK depends on when it needs to call out to a peer agent directly.
+type ConfidenceAgentClient = generated.ConfidenceAgentClient
+
+// ClientSetOptions configures the dial behavior of a RegistryClientSet.
+type ClientSetOptions struct {
+	// TransportCredentials overrides the default insecure credentials.
+	TransportCredentials credentials.TransportCredentials
+
+	// PerRPCTimeout bounds every unary RPC issued through the clientset.
+	// Zero applies no timeout.
+	PerRPCTimeout time.Duration
+
+	// DialOptions are appended after the clientset's own options, so
+	// callers can add keepalive params, interceptors, etc.
+	DialOptions []grpc.DialOption
+
+	// UnaryInterceptors / StreamInterceptors are chained in before any
+	// caller-supplied DialOptions interceptors, giving the clientset a
+	// place to install logging/retry without callers re-wiring it.
+	UnaryInterceptors  []grpc.UnaryClientInterceptor
+	StreamInterceptors []grpc.StreamClientInterceptor
+}
+
+// RegistryClientSet holds a lazily-dialed, reference-counted
+// *grpc.ClientConn per registry endpoint, following the pattern of Flyte's
+// agent ClientSet. It replaces the old practice of constructing a fresh
+// grpc.NewClient on every Register/Heartbeat/Unregister call.
+type RegistryClientSet struct {
+	opts ClientSetOptions
+
+	mu    sync.Mutex
+	conns map[string]*pooledConn
+}
+
+type pooledConn struct {
+	conn   *grpc.ClientConn
+	refs   int
+}
+
+// NewRegistryClientSet creates a RegistryClientSet with the given dial
+// options. Connections are not established until RegistryClient/AgentClient
+// is first called for an endpoint.
+func NewRegistryClientSet(opts ClientSetOptions) *RegistryClientSet {
+	return &RegistryClientSet{
+		opts:  opts,
+		conns: make(map[string]*pooledConn),
+	}
+}
+
+// Conn returns the shared *grpc.ClientConn for endpoint, dialing it on first
+// use. Callers must pair a successful call with Release once they're done
+// with that endpoint (a long-lived holder such as a lease renewer typically
+// never releases until shutdown).
+func (cs *RegistryClientSet) Conn(endpoint string) (*grpc.ClientConn, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if pc, ok := cs.conns[endpoint]; ok {
+		pc.refs++
+		return pc.conn, nil
+	}
+
+	dialOpts := cs.dialOptions()
+	conn, err := grpc.NewClient(endpoint, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial registry %s: %w", endpoint, err)
+	}
+
+	cs.conns[endpoint] = &pooledConn{conn: conn, refs: 1}
+	return conn, nil
+}
+
+// Release decrements the refcount for endpoint, closing the underlying
+// connection once the last holder releases it.
+func (cs *RegistryClientSet) Release(endpoint string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	pc, ok := cs.conns[endpoint]
+	if !ok {
+		return
+	}
+
+	pc.refs--
+	if pc.refs <= 0 {
+		pc.conn.Close()
+		delete(cs.conns, endpoint)
+	}
+}
+
+// RegistryClient returns a RegistryServiceClient bound to endpoint's shared
+// connection.
+func (cs *RegistryClientSet) RegistryClient(endpoint string) (RegistryServiceClient, error) {
+	conn, err := cs.Conn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return generated.NewRegistryClient(conn), nil
+}
+
+// AgentClient returns a ConfidenceAgentClient for dialing a peer agent
+// directly (used by pattern executors to invoke Analyze on eligible agents).
+func (cs *RegistryClientSet) AgentClient(endpoint string) (ConfidenceAgentClient, error) {
+	conn, err := cs.Conn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return generated.NewConfidenceAgentClient(conn), nil
+}
+
+// HealthClient returns a grpc_health_v1.HealthClient bound to endpoint's
+// shared connection, for probing the standard gRPC health-checking
+// protocol instead of a Parallax-specific RPC.
+func (cs *RegistryClientSet) HealthClient(endpoint string) (healthpb.HealthClient, error) {
+	conn, err := cs.Conn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return healthpb.NewHealthClient(conn), nil
+}
+
+// Close closes every pooled connection regardless of refcount. Intended for
+// final shutdown.
+func (cs *RegistryClientSet) Close() error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	var firstErr error
+	for endpoint, pc := range cs.conns {
+		if err := pc.conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(cs.conns, endpoint)
+	}
+	return firstErr
+}
+
+func (cs *RegistryClientSet) dialOptions() []grpc.DialOption {
+	creds := cs.opts.TransportCredentials
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+
+	if timeout := cs.opts.PerRPCTimeout; timeout > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}))
+	}
+	if len(cs.opts.UnaryInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(cs.opts.UnaryInterceptors...))
+	}
+	if len(cs.opts.StreamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(cs.opts.StreamInterceptors...))
+	}
+
+	return append(opts, cs.opts.DialOptions...)
+}
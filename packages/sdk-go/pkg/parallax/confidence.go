@@ -8,12 +8,18 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 // ConfidenceExtractor provides methods for extracting confidence from results
 type ConfidenceExtractor struct {
 	DefaultConfidence float64
 	Strategy          string // "llm", "keywords", or "hybrid"
+
+	// Lexicon governs "keywords"/"hybrid" scoring. Nil falls back to
+	// builtinLexicon (English); set it to a domain-specific Lexicon
+	// (legal, medical, a non-English Language, ...) to replace it.
+	Lexicon *Lexicon
 }
 
 // NewConfidenceExtractor creates a new confidence extractor
@@ -135,106 +141,250 @@ func (ce *ConfidenceExtractor) extractFromLLM(result interface{}) float64 {
 
 // extractFromKeywords extracts confidence based on keyword analysis
 func (ce *ConfidenceExtractor) extractFromKeywords(result interface{}) float64 {
-	// Convert to string
+	score, _ := ce.ExtractWithExplanation(result)
+	return score
+}
+
+// ExtractWithExplanation scores result the same way extractFromKeywords
+// does, but also returns the per-token evidence behind the score: which
+// tokens matched, what sentence they were in, the lexicon's raw weight
+// for that token, and the weight actually applied after the
+// negation/intensifier pass. Use this instead of Extract/extractFromKeywords
+// when a caller wants to cite specific evidence rather than log a bare
+// score — WithConfidencePolicy's warn/audit actions do this directly
+// against builtinLexicon (see citeEvidence/AuditEvent.Evidence), since
+// they score AgentResult.Reasoning rather than an arbitrary result value.
+func (ce *ConfidenceExtractor) ExtractWithExplanation(result interface{}) (float64, []TokenEvidence) {
 	var text string
 	if jsonBytes, err := json.Marshal(result); err == nil {
 		text = string(jsonBytes)
 	} else {
 		text = fmt.Sprintf("%v", result)
 	}
-	text = strings.ToLower(text)
-	
-	score := ce.DefaultConfidence
-	
-	// High confidence indicators
-	highConfidenceWords := map[string]float64{
-		"definitely":      0.15,
-		"certainly":       0.15,
-		"absolutely":      0.15,
-		"confirmed":       0.15,
-		"verified":        0.15,
-		"guaranteed":      0.15,
-		"certain":         0.12,
-		"sure":            0.12,
-		"clear":           0.10,
-		"obvious":         0.10,
-		"undoubtedly":     0.12,
-		"unquestionably":  0.12,
-		"conclusive":      0.12,
-		"definitive":      0.12,
-		"established":     0.10,
-	}
-	
-	// Medium confidence indicators
-	mediumConfidenceWords := map[string]float64{
-		"probably":    0.05,
-		"likely":      0.05,
-		"appears":     0.05,
-		"seems":       0.05,
-		"suggests":    0.05,
-		"indicates":   0.05,
-		"mostly":      0.04,
-		"generally":   0.04,
-		"typically":   0.04,
-		"reasonable":  0.05,
-		"plausible":   0.05,
-		"expected":    0.04,
-	}
-	
-	// Low confidence indicators
-	lowConfidenceWords := map[string]float64{
-		"possibly":      -0.15,
-		"maybe":         -0.15,
-		"might":         -0.12,
-		"could":         -0.10,
-		"uncertain":     -0.15,
-		"unclear":       -0.15,
-		"unsure":        -0.15,
-		"doubt":         -0.15,
-		"guess":         -0.12,
-		"assume":        -0.10,
-		"questionable":  -0.15,
-		"tentative":     -0.12,
-		"approximate":   -0.08,
-		"estimated":     -0.08,
-		"roughly":       -0.08,
+
+	delta, evidence := ce.lexicon().score(text)
+	score := math.Max(0.1, math.Min(0.95, ce.DefaultConfidence+delta))
+	return score, evidence
+}
+
+// lexicon returns ce.Lexicon, falling back to builtinLexicon when unset.
+func (ce *ConfidenceExtractor) lexicon() *Lexicon {
+	if ce.Lexicon != nil {
+		return ce.Lexicon
 	}
-	
-	// Apply modifiers
-	for word, modifier := range highConfidenceWords {
-		if strings.Contains(text, word) {
-			score += modifier
-		}
+	return builtinLexicon
+}
+
+// TokenEvidence records one lexicon word's contribution to a
+// ConfidenceExtractor.ExtractWithExplanation score.
+type TokenEvidence struct {
+	// Token is the lowercased word that matched a Lexicon entry.
+	Token string
+
+	// SentenceIndex is which sentence (0-based, split on .!?) Token
+	// appeared in. Negation/intensifier windows never cross a sentence
+	// boundary, so this also scopes which other evidence it was judged
+	// alongside.
+	SentenceIndex int
+
+	// RawWeight is the weight Lexicon.Words[Token] assigns before any
+	// negation/intensifier adjustment.
+	RawWeight float64
+
+	// Weight is RawWeight after negation (sign flip) and intensifier
+	// (magnitude scale) are applied. This is what was actually added to
+	// the score.
+	Weight float64
+}
+
+// citeEvidence renders evidence as a short human-readable string citing
+// the specific tokens that drove it, e.g. "probably(+0.05),
+// uncertain(-0.15)", in the order ExtractWithExplanation found them. Used
+// anywhere a bare confidence float isn't enough to explain a decision,
+// e.g. WithConfidencePolicy's warn/audit actions.
+func citeEvidence(evidence []TokenEvidence) string {
+	if len(evidence) == 0 {
+		return ""
 	}
-	
-	for word, modifier := range mediumConfidenceWords {
-		if strings.Contains(text, word) {
-			score += modifier
-		}
+	parts := make([]string, len(evidence))
+	for i, e := range evidence {
+		parts[i] = fmt.Sprintf("%s(%+.2f)", e.Token, e.Weight)
 	}
-	
-	for word, modifier := range lowConfidenceWords {
-		if strings.Contains(text, word) {
-			score += modifier
-		}
+	return strings.Join(parts, ", ")
+}
+
+// Lexicon is the set of confidence-indicating words, negators, and
+// intensifiers ConfidenceExtractor scores text against in "keywords" and
+// "hybrid" mode. builtinLexicon covers common English hedging/certainty
+// vocabulary; set ConfidenceExtractor.Lexicon to a domain-specific one
+// (legal, medical, ...) to replace it outright.
+type Lexicon struct {
+	// Language identifies the natural language Words/Negators/Intensifiers
+	// are written in (e.g. "en"). Informational only — tokenization itself
+	// is unicode-aware and doesn't branch on it — but it documents which
+	// lexicon is in play and lets a non-English Lexicon be registered
+	// without touching the scoring algorithm.
+	Language string
+
+	// Words maps a lowercased token to the score delta it contributes the
+	// first time it appears in a sentence. Later occurrences of the same
+	// token in the same sentence are ignored, so repetition can't inflate
+	// the score.
+	Words map[string]float64
+
+	// Negators are lowercased tokens that flip the sign of a matched
+	// word's weight when one appears within NegationWindow tokens before
+	// it, in the same sentence.
+	Negators []string
+
+	// Intensifiers maps a lowercased token to the multiplier applied to a
+	// matched word's weight when one appears within NegationWindow tokens
+	// before it, in the same sentence.
+	Intensifiers map[string]float64
+
+	// NegationWindow is how many preceding tokens (within the same
+	// sentence) are checked for a negator/intensifier. Defaults to 3 when
+	// zero.
+	NegationWindow int
+}
+
+// wordTokenRe extracts unicode-aware word tokens, so a Lexicon.Words
+// entry matches whole words only and never a substring of a longer word
+// (e.g. "certain" no longer matches inside "uncertain").
+var wordTokenRe = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// sentenceSplitRe delimits the sentence boundaries a negation/intensifier
+// window never crosses.
+var sentenceSplitRe = regexp.MustCompile(`[.!?]+`)
+
+// builtinLexicon is the default English Lexicon, used whenever a
+// ConfidenceExtractor doesn't set its own.
+var builtinLexicon = DefaultLexicon()
+
+// DefaultLexicon returns the SDK's built-in English confidence lexicon.
+func DefaultLexicon() *Lexicon {
+	return &Lexicon{
+		Language: "en",
+		Words: map[string]float64{
+			// High confidence indicators
+			"definitely":     0.15,
+			"certainly":      0.15,
+			"absolutely":     0.15,
+			"confirmed":      0.15,
+			"verified":       0.15,
+			"guaranteed":     0.15,
+			"certain":        0.12,
+			"sure":           0.12,
+			"clear":          0.10,
+			"obvious":        0.10,
+			"undoubtedly":    0.12,
+			"unquestionably": 0.12,
+			"conclusive":     0.12,
+			"definitive":     0.12,
+			"established":    0.10,
+
+			// Medium confidence indicators
+			"probably":   0.05,
+			"likely":     0.05,
+			"appears":    0.05,
+			"seems":      0.05,
+			"suggests":   0.05,
+			"indicates":  0.05,
+			"mostly":     0.04,
+			"generally":  0.04,
+			"typically":  0.04,
+			"reasonable": 0.05,
+			"plausible":  0.05,
+			"expected":   0.04,
+
+			// Low confidence indicators
+			"possibly":     -0.15,
+			"maybe":        -0.15,
+			"might":        -0.12,
+			"could":        -0.10,
+			"uncertain":    -0.15,
+			"unclear":      -0.15,
+			"unsure":       -0.15,
+			"doubt":        -0.15,
+			"guess":        -0.12,
+			"assume":       -0.10,
+			"questionable": -0.15,
+			"tentative":    -0.12,
+			"approximate":  -0.08,
+			"estimated":    -0.08,
+			"roughly":      -0.08,
+
+			// Hedging verbs, folded into the word lexicon instead of a
+			// separate regex pass so they get the same per-sentence
+			// dedup and negation/intensifier treatment as everything else.
+			"think":      -0.05,
+			"believe":    -0.05,
+			"suppose":    -0.05,
+			"perhaps":    -0.08,
+			"presumably": -0.08,
+		},
+		Negators:       []string{"not", "no", "never", "hardly"},
+		Intensifiers:   map[string]float64{"very": 1.5, "highly": 1.5, "extremely": 1.75, "somewhat": 0.6, "slightly": 0.5},
+		NegationWindow: 3,
 	}
-	
-	// Check for hedging patterns
-	hedgingPatterns := []string{
-		`(?:i|we)\s+(?:think|believe|suppose)`,
-		`(?:may|might)\s+be`,
-		`(?:could|would)\s+(?:be|suggest)`,
-		`(?:perhaps|presumably)`,
+}
+
+// score tokenizes text sentence by sentence and sums the weight of every
+// Lexicon.Words match, applying the negation/intensifier pass within each
+// match's own sentence. It returns the total delta and the evidence
+// behind it, in the order tokens were encountered.
+func (l *Lexicon) score(text string) (float64, []TokenEvidence) {
+	window := l.NegationWindow
+	if window <= 0 {
+		window = 3
 	}
-	
-	for _, pattern := range hedgingPatterns {
-		if matched, _ := regexp.MatchString(pattern, text); matched {
-			score -= 0.1
+
+	var total float64
+	var evidence []TokenEvidence
+
+	for sentenceIdx, sentence := range sentenceSplitRe.Split(strings.ToLower(text), -1) {
+		tokens := wordTokenRe.FindAllString(sentence, -1)
+		seen := make(map[string]bool, len(tokens))
+
+		for i, token := range tokens {
+			raw, ok := l.Words[token]
+			if !ok || seen[token] {
+				continue
+			}
+			seen[token] = true
+
+			start := i - window
+			if start < 0 {
+				start = 0
+			}
+
+			negated := false
+			scale := 1.0
+			for _, ctx := range tokens[start:i] {
+				if containsString(l.Negators, ctx) {
+					negated = true
+				}
+				if factor, ok := l.Intensifiers[ctx]; ok {
+					scale = factor
+				}
+			}
+
+			weight := raw * scale
+			if negated {
+				weight = -weight
+			}
+
+			total += weight
+			evidence = append(evidence, TokenEvidence{
+				Token:         token,
+				SentenceIndex: sentenceIdx,
+				RawWeight:     raw,
+				Weight:        weight,
+			})
 		}
 	}
-	
-	// Clamp to valid range
-	return math.Max(0.1, math.Min(0.95, score))
+
+	return total, evidence
 }
 
 // normalizeConfidence normalizes a value to 0.0-1.0 range
@@ -405,6 +555,69 @@ func (ca *ConfidenceAggregator) Calibrate(rawConfidence float64, calibration *Ca
 	return math.Max(0.0, math.Min(1.0, calibrated))
 }
 
+// calibrationLearningRate bounds how much a single ConfidenceCalibrator.Observe
+// nudges an agent's Bias, so one noisy execution doesn't swing its
+// calibration.
+const calibrationLearningRate = 0.1
+
+// ConfidenceCalibrator maintains per-agent CalibrationData, keeping it
+// updated from the consensus-builder pattern's actual cross-agent
+// agreement — the only place in the SDK that knows a "downstream
+// consensus" value to compare an agent's self-reported confidence
+// against (see consensusBuilderExecutor.Execute). The metrics
+// interceptor (WithMetrics) reads calibration back via Calibrated, for
+// callers that only ever see one agent's confidence at a time and have
+// no consensus of their own to Observe against.
+type ConfidenceCalibrator struct {
+	aggregator ConfidenceAggregator
+
+	mu   sync.Mutex
+	data map[string]*CalibrationData
+}
+
+// NewConfidenceCalibrator creates an empty ConfidenceCalibrator; every
+// agent starts uncalibrated (Calibrated is a no-op) until Observe has run
+// at least once for it.
+func NewConfidenceCalibrator() *ConfidenceCalibrator {
+	return &ConfidenceCalibrator{data: make(map[string]*CalibrationData)}
+}
+
+// Observe records agentID's self-reported confidence against consensus,
+// nudging that agent's CalibrationData.Bias toward the observed
+// divergence by calibrationLearningRate, and returns the newly
+// calibrated confidence.
+func (c *ConfidenceCalibrator) Observe(agentID string, selfConfidence, consensus float64) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cal := c.dataForLocked(agentID)
+	cal.Bias += calibrationLearningRate * (selfConfidence - consensus)
+
+	return c.aggregator.Calibrate(selfConfidence, cal)
+}
+
+// Calibrated applies agentID's current CalibrationData to raw without
+// updating it. Agents Observe hasn't run for yet are returned unchanged.
+func (c *ConfidenceCalibrator) Calibrated(agentID string, raw float64) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cal, ok := c.data[agentID]
+	if !ok {
+		return raw
+	}
+	return c.aggregator.Calibrate(raw, cal)
+}
+
+func (c *ConfidenceCalibrator) dataForLocked(agentID string) *CalibrationData {
+	cal, ok := c.data[agentID]
+	if !ok {
+		cal = &CalibrationData{Scale: 1}
+		c.data[agentID] = cal
+	}
+	return cal
+}
+
 // RequireMinimumConfidence wraps a function to ensure minimum confidence
 func RequireMinimumConfidence(minConfidence float64, analyzeFunc func(ctx context.Context, task string, data interface{}) (*AgentResult, error)) func(ctx context.Context, task string, data interface{}) (*AgentResult, error) {
 	return func(ctx context.Context, task string, data interface{}) (*AgentResult, error) {
@@ -0,0 +1,284 @@
+package parallax
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	registry "parallax/sdk-go/generated"
+)
+
+// TracingConfig is the tracing half of a control-plane config push: where
+// to export spans and at what sample rate. When WithTracer wasn't given,
+// GrpcAgent.tracer builds its OTLP exporter from it on first use; a push
+// that arrives after that is stored but does not rebuild the exporter.
+type TracingConfig struct {
+	Endpoint   string
+	SampleRate float64
+}
+
+// RateLimitConfig caps Analyze throughput per task name, enforced by a
+// token bucket per task inside configState.allow.
+type RateLimitConfig struct {
+	RPS map[string]float64
+}
+
+// TaskPolicy restricts which tasks GrpcAgent.Analyze accepts and floors
+// the confidence it reports. Allowed, if non-empty, is an allowlist;
+// Denied always wins over Allowed for a task present in both.
+type TaskPolicy struct {
+	Allowed         []string
+	Denied          []string
+	ConfidenceFloor float64
+}
+
+// CapabilityOverrides adds or removes entries from what GetCapabilities
+// reports, without requiring the agent binary to restart.
+type CapabilityOverrides struct {
+	Add    []string
+	Remove []string
+}
+
+// agentConfig is the config state ConfigPush messages apply to, guarded by
+// configState.mu. Version is monotonic; a push whose Version doesn't
+// exceed the currently applied one is dropped, since ConfigStream
+// reconnects can otherwise redeliver a push the agent already has.
+type agentConfig struct {
+	version             int64
+	tracing             TracingConfig
+	rateLimit           RateLimitConfig
+	taskPolicy          TaskPolicy
+	capabilityOverrides CapabilityOverrides
+}
+
+// configState holds the config GrpcAgent.runConfigStream receives from the
+// control plane and the token buckets RateLimitConfig is enforced through.
+// Analyze/GetCapabilities read it via RLock on every call, so a config push
+// takes effect on the very next RPC rather than waiting for a poll.
+type configState struct {
+	mu      sync.RWMutex
+	cfg     agentConfig
+	buckets map[string]*tokenBucket
+}
+
+// newConfigState returns a configState with no policy applied yet, i.e.
+// GrpcAgent behaves exactly as it did before ConfigStream existed.
+func newConfigState() *configState {
+	return &configState{buckets: make(map[string]*tokenBucket)}
+}
+
+// apply merges push into cfg, replacing only the sections push actually
+// carries (a nil section means "unchanged"), and resets rate-limit buckets
+// whenever RateLimit changes so a new RPS ceiling takes effect immediately
+// instead of being blended with the old bucket's accumulated tokens.
+func (s *configState) apply(push *registry.ConfigPush) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if push.Version <= s.cfg.version {
+		return
+	}
+	s.cfg.version = push.Version
+
+	if push.Tracing != nil {
+		s.cfg.tracing = TracingConfig{Endpoint: push.Tracing.Endpoint, SampleRate: push.Tracing.SampleRate}
+	}
+	if push.RateLimit != nil {
+		s.cfg.rateLimit = RateLimitConfig{RPS: push.RateLimit.Rps}
+		s.buckets = make(map[string]*tokenBucket)
+	}
+	if push.TaskPolicy != nil {
+		s.cfg.taskPolicy = TaskPolicy{
+			Allowed:         push.TaskPolicy.Allowed,
+			Denied:          push.TaskPolicy.Denied,
+			ConfidenceFloor: push.TaskPolicy.ConfidenceFloor,
+		}
+	}
+	if push.CapabilityOverrides != nil {
+		s.cfg.capabilityOverrides = CapabilityOverrides{
+			Add:    push.CapabilityOverrides.Add,
+			Remove: push.CapabilityOverrides.Remove,
+		}
+	}
+}
+
+// version returns the currently applied config version, 0 until the first
+// push is received.
+func (s *configState) version() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.version
+}
+
+// checkTask returns a codes.PermissionDenied error if the pushed TaskPolicy
+// forbids task, nil otherwise. Denied always wins over Allowed for a task
+// present in both, and an empty Allowed permits everything not in Denied.
+func (s *configState) checkTask(task string) error {
+	s.mu.RLock()
+	policy := s.cfg.taskPolicy
+	s.mu.RUnlock()
+
+	if containsString(policy.Denied, task) {
+		return status.Errorf(codes.PermissionDenied, "task %q is denied by control-plane policy", task)
+	}
+	if len(policy.Allowed) > 0 && !containsString(policy.Allowed, task) {
+		return status.Errorf(codes.PermissionDenied, "task %q is not in the control-plane allowed list", task)
+	}
+	return nil
+}
+
+// clampConfidence raises confidence to the pushed TaskPolicy's
+// ConfidenceFloor when it falls below it, leaving it unchanged otherwise
+// (a floor of 0, the zero value, never clamps).
+func (s *configState) clampConfidence(confidence float64) float64 {
+	s.mu.RLock()
+	floor := s.cfg.taskPolicy.ConfidenceFloor
+	s.mu.RUnlock()
+
+	if floor > 0 && confidence < floor {
+		return floor
+	}
+	return confidence
+}
+
+// allow reports whether task may proceed under the pushed RateLimitConfig,
+// consuming a token from task's bucket if so. A task with no configured
+// RPS (or an RPS of 0) is always allowed, matching GrpcAgent's behavior
+// before any RateLimitConfig was pushed.
+func (s *configState) allow(task string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rps := s.cfg.rateLimit.RPS[task]
+	if rps <= 0 {
+		return true
+	}
+
+	b, ok := s.buckets[task]
+	if !ok {
+		b = newTokenBucket(rps)
+		s.buckets[task] = b
+	}
+	return b.allow()
+}
+
+// applyCapabilityOverrides returns base with the pushed CapabilityOverrides
+// applied: Remove entries dropped, then Add entries appended (skipping
+// duplicates). Returns base unchanged when no overrides are pushed.
+func (s *configState) applyCapabilityOverrides(base []string) []string {
+	s.mu.RLock()
+	overrides := s.cfg.capabilityOverrides
+	s.mu.RUnlock()
+
+	if len(overrides.Add) == 0 && len(overrides.Remove) == 0 {
+		return base
+	}
+
+	result := make([]string, 0, len(base)+len(overrides.Add))
+	for _, c := range base {
+		if !containsString(overrides.Remove, c) {
+			result = append(result, c)
+		}
+	}
+	for _, c := range overrides.Add {
+		if !containsString(result, c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// tokenBucket is a minimal leaky-bucket rate limiter keyed by task inside
+// configState.buckets, refilling continuously at rate tokens/second up to
+// a burst of one second's worth of tokens.
+type tokenBucket struct {
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rps float64) *tokenBucket {
+	return &tokenBucket{rate: rps, tokens: rps, last: timeNow()}
+}
+
+// allow reports whether a token is available, consuming one if so. Callers
+// must hold the configState lock; tokenBucket has no locking of its own.
+func (b *tokenBucket) allow() bool {
+	now := timeNow()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// runConfigStream opens ConfigStream against the balancer's current
+// endpoint and applies every ConfigPush it receives, until g.stopCh
+// closes. A disconnect (stream error or clean EOF) reconnects using
+// g.opts.backoff, the same policy register uses, and the agent keeps
+// serving with its last-known config in the meantime.
+func (g *GrpcAgent) runConfigStream() {
+	defer g.wg.Done()
+
+	var attempt int
+	for {
+		select {
+		case <-g.stopCh:
+			return
+		default:
+		}
+
+		if err := g.streamConfigOnce(); err != nil {
+			log.Printf("config stream disconnected, continuing with last-known config: %v", err)
+			attempt++
+		} else {
+			attempt = 0
+		}
+
+		select {
+		case <-g.stopCh:
+			return
+		case <-time.After(g.opts.backoff.delay(attempt)):
+		}
+	}
+}
+
+// streamConfigOnce opens one ConfigStream call and applies pushes from it
+// until the stream ends, marking the balancer's endpoint unhealthy on
+// failure so the next reconnect fails over like register/renew do.
+func (g *GrpcAgent) streamConfigOnce() error {
+	client, addr, _, err := g.balancer.registryClient()
+	if err != nil {
+		return fmt.Errorf("failed to select registry endpoint: %w", err)
+	}
+
+	stream, err := client.ConfigStream(context.Background(), &registry.ConfigStreamRequest{AgentId: g.agent.GetID()})
+	if err != nil {
+		g.balancer.markUnhealthy(addr, err)
+		return fmt.Errorf("failed to open config stream to %s: %w", addr, err)
+	}
+
+	for {
+		push, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			g.balancer.markUnhealthy(addr, err)
+			return fmt.Errorf("config stream from %s failed: %w", addr, err)
+		}
+		g.config.apply(push)
+	}
+}
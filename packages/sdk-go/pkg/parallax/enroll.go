@@ -0,0 +1,285 @@
+package parallax
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"parallax/sdk-go/generated"
+)
+
+// EnrollmentServiceClient is the subset of generated.EnrollmentClient the
+// SDK depends on, following RegistryServiceClient's alias convention so
+// tests can supply a generated mock instead of dialing a real control
+// plane.
+type EnrollmentServiceClient = generated.EnrollmentClient
+
+// EnrollmentClient returns an EnrollmentServiceClient bound to endpoint's
+// shared connection.
+func (cs *RegistryClientSet) EnrollmentClient(endpoint string) (EnrollmentServiceClient, error) {
+	conn, err := cs.Conn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return generated.NewEnrollmentClient(conn), nil
+}
+
+// AgentCredentials is the per-agent identity an EnrollmentService.Enroll
+// or Heartbeat call returns: an mTLS client certificate/key (for dialing
+// the control plane and peer agents going forward) plus a rotating
+// refresh token used to renew both before ExpiresAt.
+type AgentCredentials struct {
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+	CABundlePEM   []byte
+	RefreshToken  string
+	ExpiresAt     time.Time
+}
+
+// EnrollConfig configures EnrollAndServe's bootstrap handshake against a
+// control plane's EnrollmentService.
+type EnrollConfig struct {
+	// Token is the enrollment token issued by
+	// EnrollmentService.CreateEnrollmentToken. Set this directly, or
+	// leave it empty and set TokenFile instead.
+	Token string
+
+	// TokenFile, when Token is empty, is read for the token. Operators
+	// mount this from agent.Spec.EnrollmentTokenRef at
+	// /var/run/parallax/enroll and set it via the
+	// PARALLAX_ENROLLMENT_TOKEN_FILE env var, so tokens never need to be
+	// baked into an agent image.
+	TokenFile string
+
+	// ControlPlaneURL is dialed insecurely to perform the initial Enroll
+	// call, before the agent holds a certificate of its own.
+	ControlPlaneURL string
+
+	// CredentialDir is a writable directory EnrollAndServe persists the
+	// issued certificate, key, CA bundle, and refresh token into
+	// (cert.pem/key.pem/ca.pem/refresh_token), and re-persists into on
+	// every rotation.
+	CredentialDir string
+
+	// RenewBefore is how long before ExpiresAt the credential lifecycle
+	// loop proactively rotates it. Defaults to 1 hour.
+	RenewBefore time.Duration
+}
+
+// resolveToken returns cfg.Token, or the contents of cfg.TokenFile if
+// Token is empty.
+func (cfg EnrollConfig) resolveToken() (string, error) {
+	if cfg.Token != "" {
+		return cfg.Token, nil
+	}
+	if cfg.TokenFile == "" {
+		return "", fmt.Errorf("enroll: one of Token or TokenFile must be set")
+	}
+	data, err := os.ReadFile(cfg.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("enroll: read token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// renewBefore returns cfg.RenewBefore, defaulting to 1 hour.
+func (cfg EnrollConfig) renewBefore() time.Duration {
+	if cfg.RenewBefore > 0 {
+		return cfg.RenewBefore
+	}
+	return time.Hour
+}
+
+// enroll dials cfg.ControlPlaneURL insecurely and exchanges token for
+// agent's first AgentCredentials.
+func enroll(ctx context.Context, cfg EnrollConfig, token string, agent Agent) (*AgentCredentials, error) {
+	conn, err := grpc.NewClient(cfg.ControlPlaneURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("enroll: dial control plane: %w", err)
+	}
+	defer conn.Close()
+
+	client := generated.NewEnrollmentClient(conn)
+
+	resp, err := client.Enroll(ctx, &generated.EnrollRequest{
+		Token: token,
+		Agent: &generated.AgentRegistration{
+			Id:           agent.GetID(),
+			Name:         agent.GetName(),
+			Capabilities: agent.GetCapabilities(),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("enroll: %w", err)
+	}
+
+	return credentialsFromProto(resp), nil
+}
+
+// heartbeat exchanges refreshToken for a (possibly rotated)
+// AgentCredentials over the already-enrolled mTLS connection in
+// clientSet, renewing the agent's lease the same way
+// agentService.Heartbeat does for the legacy Register path.
+func heartbeat(ctx context.Context, clientSet *RegistryClientSet, endpoint, refreshToken string) (*AgentCredentials, error) {
+	client, err := clientSet.EnrollmentClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Heartbeat(ctx, &generated.AgentHeartbeatRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return nil, fmt.Errorf("enrollment heartbeat: %w", err)
+	}
+	return credentialsFromProto(resp), nil
+}
+
+func credentialsFromProto(resp *generated.AgentCredentials) *AgentCredentials {
+	creds := &AgentCredentials{
+		ClientCertPEM: resp.GetClientCertPem(),
+		ClientKeyPEM:  resp.GetClientKeyPem(),
+		CABundlePEM:   resp.GetCaBundlePem(),
+		RefreshToken:  resp.GetRefreshToken(),
+	}
+	if resp.GetExpiresAt() != nil {
+		creds.ExpiresAt = resp.GetExpiresAt().AsTime()
+	}
+	return creds
+}
+
+// persist writes creds' certificate, key, CA bundle, and refresh token
+// into dir, returning the cert/key/CA file paths for wiring into a
+// TLSConfig. File modes are restrictive since these are bearer
+// credentials.
+func (creds *AgentCredentials) persist(dir string) (certFile, keyFile, caFile string, err error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", "", fmt.Errorf("enroll: create credential dir: %w", err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	caFile = filepath.Join(dir, "ca.pem")
+	tokenFile := filepath.Join(dir, "refresh_token")
+
+	writes := []struct {
+		path string
+		data []byte
+		mode os.FileMode
+	}{
+		{certFile, creds.ClientCertPEM, 0600},
+		{keyFile, creds.ClientKeyPEM, 0600},
+		{caFile, creds.CABundlePEM, 0600},
+		{tokenFile, []byte(creds.RefreshToken), 0600},
+	}
+	for _, w := range writes {
+		if err := os.WriteFile(w.path, w.data, w.mode); err != nil {
+			return "", "", "", fmt.Errorf("enroll: write %s: %w", w.path, err)
+		}
+	}
+
+	return certFile, keyFile, caFile, nil
+}
+
+// EnrollAndServe performs the bootstrap enrollment handshake described by
+// cfg, persists the resulting AgentCredentials under cfg.CredentialDir,
+// starts a background loop that transparently rotates them via Heartbeat
+// before ExpiresAt, and then serves agent the same way ServeAgent does.
+// The issued certificate/key/CA bundle are built into a GrpcTLSConfig and
+// applied via WithTLS, so both the embedded server and every outbound
+// dial (the control-plane client set the rotation heartbeat uses, and the
+// registry/peer-agent dials ServeAgent's GrpcAgent makes) present the
+// enrolled mTLS identity, not just the insecure bootstrap connection
+// enroll used. Because runCredentialLifecycle persists each rotation to
+// the same cert.pem/key.pem paths, and GrpcTLSConfig.clientCredentials/
+// serverCredentials watch those paths for changes (see grpcCertWatcher),
+// a rotated certificate is picked up by every live connection on its next
+// handshake without restarting the agent. It returns once the server
+// shuts down (signal or Stop), the same as ServeAgent.
+func EnrollAndServe(agent Agent, port int, cfg EnrollConfig, opts ...ServeAgentOption) error {
+	token, err := cfg.resolveToken()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	creds, err := enroll(ctx, cfg, token, agent)
+	if err != nil {
+		return err
+	}
+
+	certFile, keyFile, caFile, err := creds.persist(cfg.CredentialDir)
+	if err != nil {
+		return err
+	}
+	log.Printf("Agent %s enrolled with control plane, credential expires %s", agent.GetID(), creds.ExpiresAt)
+
+	tlsCfg := GrpcTLSConfig{
+		CAFile:         caFile,
+		ClientCertFile: certFile,
+		ClientKeyFile:  keyFile,
+		ServerCertFile: certFile,
+		ServerKeyFile:  keyFile,
+	}
+	tlsCreds, err := tlsCfg.clientCredentials()
+	if err != nil {
+		return fmt.Errorf("enroll: build mTLS credentials from issued certificate: %w", err)
+	}
+	defer tlsCreds.Close()
+
+	clientSet := NewRegistryClientSet(ClientSetOptions{TransportCredentials: tlsCreds})
+	defer clientSet.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go runCredentialLifecycle(cfg, clientSet, agent.GetID(), creds, stop)
+
+	opts = append(opts, WithTLS(tlsCfg))
+	return ServeAgent(agent, port, opts...)
+}
+
+// runCredentialLifecycle re-heartbeats the credential RenewBefore ahead of
+// its expiry (at once if that point has already passed), retrying 30
+// seconds after a failed heartbeat, until stop is closed, persisting each
+// rotation under cfg.CredentialDir.
+func runCredentialLifecycle(cfg EnrollConfig, clientSet *RegistryClientSet, agentID string, creds *AgentCredentials, stop <-chan struct{}) {
+	for {
+		wait := time.Until(creds.ExpiresAt) - cfg.renewBefore()
+		if wait < 0 {
+			wait = 0
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		rotated, err := heartbeat(ctx, clientSet, cfg.ControlPlaneURL, creds.RefreshToken)
+		cancel()
+		if err != nil {
+			log.Printf("Agent %s failed to rotate enrollment credential: %v", agentID, err)
+			// Back off briefly rather than hammering a control plane
+			// that's already rejecting this token.
+			select {
+			case <-stop:
+				return
+			case <-time.After(30 * time.Second):
+			}
+			continue
+		}
+
+		if _, _, _, err := rotated.persist(cfg.CredentialDir); err != nil {
+			log.Printf("Agent %s failed to persist rotated enrollment credential: %v", agentID, err)
+			continue
+		}
+		log.Printf("Agent %s rotated enrollment credential, now expires %s", agentID, rotated.ExpiresAt)
+		creds = rotated
+	}
+}
@@ -15,9 +15,22 @@ type executionService struct {
 	logger *zap.Logger
 }
 
+// executionClient wraps the clientset's shared connection for the control
+// plane endpoint rather than dialing or re-wrapping s.client.conn directly.
+func (s *executionService) executionClient() (generated.ExecutionServiceClient, error) {
+	conn, err := s.client.clientSet.Conn(s.client.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return generated.NewExecutionServiceClient(conn), nil
+}
+
 // Get returns a specific execution by ID
 func (s *executionService) Get(ctx context.Context, id string) (*PatternExecution, error) {
-	client := generated.NewExecutionServiceClient(s.client.conn)
+	client, err := s.executionClient()
+	if err != nil {
+		return nil, err
+	}
 	response, err := client.GetExecution(ctx, &generated.GetExecutionRequest{ExecutionId: id})
 	if err != nil {
 		return nil, err
@@ -28,7 +41,10 @@ func (s *executionService) Get(ctx context.Context, id string) (*PatternExecutio
 
 // List returns recent executions
 func (s *executionService) List(ctx context.Context, limit int, offset int, status string) ([]*PatternExecution, error) {
-	client := generated.NewExecutionServiceClient(s.client.conn)
+	client, err := s.executionClient()
+	if err != nil {
+		return nil, err
+	}
 	response, err := client.ListExecutions(ctx, &generated.ListExecutionsRequest{
 		Limit:  int32(limit),
 		Offset: int32(offset),
@@ -48,7 +64,10 @@ func (s *executionService) List(ctx context.Context, limit int, offset int, stat
 
 // Stream streams execution updates for a specific execution
 func (s *executionService) Stream(ctx context.Context, id string) (<-chan *PatternExecution, error) {
-	client := generated.NewExecutionServiceClient(s.client.conn)
+	client, err := s.executionClient()
+	if err != nil {
+		return nil, err
+	}
 	stream, err := client.StreamExecution(ctx, &generated.StreamExecutionRequest{
 		ExecutionId: id,
 	})
@@ -0,0 +1,144 @@
+// Package gateway bridges the SDK's streaming RPCs (pattern execution
+// updates, agent registry updates) to browser clients that can't open a
+// native gRPC connection: gRPC-Web for clients using a generated grpc-web
+// stub, and a lightweight WebSocket/JSON bridge for a plain web dashboard.
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"parallax/sdk-go/pkg/parallax"
+)
+
+// Default buffer sizes match net/http's historical 32 KiB request / 64 KiB
+// response defaults. Execution results carrying model output routinely
+// exceed these, so operators are expected to raise them via
+// WithMaxRequestBodyBufferSize/WithMaxRespBodyBufferSize.
+const (
+	defaultMaxRequestBodyBufferSize = 32 * 1024
+	defaultMaxRespBodyBufferSize    = 64 * 1024
+	defaultBackpressureBufferSize   = 32
+)
+
+// Gateway exposes a Client's streaming RPCs to browser clients over
+// gRPC-Web and a WebSocket/JSON bridge. The zero value is not usable; build
+// one with New.
+type Gateway struct {
+	client     *parallax.Client
+	logger     *zap.Logger
+	grpcWebSrv *grpcweb.WrappedGrpcServer
+
+	maxRequestBodyBufferSize int
+	maxRespBodyBufferSize    int
+	backpressureBufferSize   int
+	allowedOrigins           map[string]struct{}
+}
+
+// Option configures a Gateway at construction time.
+type Option func(*Gateway)
+
+// WithLogger overrides the gateway's logger. Defaults to zap.NewNop().
+func WithLogger(logger *zap.Logger) Option {
+	return func(g *Gateway) { g.logger = logger }
+}
+
+// WithGRPCServer wraps server for gRPC-Web access alongside the WebSocket
+// bridge. Omit it to serve only the WebSocket bridge.
+func WithGRPCServer(server *grpc.Server) Option {
+	return func(g *Gateway) {
+		g.grpcWebSrv = grpcweb.WrapServer(server, grpcweb.WithOriginFunc(g.isAllowedOrigin))
+	}
+}
+
+// WithMaxRequestBodyBufferSize sets each WebSocket connection's read buffer
+// size and the largest inbound message it accepts. Defaults to 32 KiB. The
+// bridge's endpoints only stream to the client; they never read messages
+// from it.
+func WithMaxRequestBodyBufferSize(n int) Option {
+	return func(g *Gateway) { g.maxRequestBodyBufferSize = n }
+}
+
+// WithMaxRespBodyBufferSize caps how large a single outbound WebSocket
+// frame's JSON payload may be; a larger one is logged and dropped instead
+// of written, leaving a gap in Frame.Seq. Defaults to 64 KiB;
+// execution results carrying model output routinely exceed that default,
+// so dashboards subscribing to StreamExecution events usually need this
+// raised.
+func WithMaxRespBodyBufferSize(n int) Option {
+	return func(g *Gateway) { g.maxRespBodyBufferSize = n }
+}
+
+// WithBackpressureBufferSize sets how many pending frames the gateway
+// queues per WebSocket connection before dropping the oldest rather than
+// blocking the underlying Stream/StreamAgents channel on a slow reader.
+// Defaults to 32.
+func WithBackpressureBufferSize(n int) Option {
+	return func(g *Gateway) { g.backpressureBufferSize = n }
+}
+
+// WithOriginAllowlist restricts both the WebSocket bridge and gRPC-Web
+// endpoint to the given Origin header values. An empty allowlist (the
+// default) allows any origin; set this in production.
+func WithOriginAllowlist(origins ...string) Option {
+	return func(g *Gateway) {
+		for _, o := range origins {
+			g.allowedOrigins[o] = struct{}{}
+		}
+	}
+}
+
+// New builds a Gateway serving client's pattern-execution and agent
+// streams to browser clients.
+func New(client *parallax.Client, opts ...Option) *Gateway {
+	g := &Gateway{
+		client:                   client,
+		logger:                   zap.NewNop(),
+		maxRequestBodyBufferSize: defaultMaxRequestBodyBufferSize,
+		maxRespBodyBufferSize:    defaultMaxRespBodyBufferSize,
+		backpressureBufferSize:   defaultBackpressureBufferSize,
+		allowedOrigins:           make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// isAllowedOrigin reports whether origin is permitted, honoring an empty
+// allowlist as "allow any origin".
+func (g *Gateway) isAllowedOrigin(origin string) bool {
+	if len(g.allowedOrigins) == 0 {
+		return true
+	}
+	_, ok := g.allowedOrigins[origin]
+	return ok
+}
+
+// Handler returns an http.Handler serving:
+//
+//   - gRPC-Web requests, if WithGRPCServer was supplied
+//   - GET /ws/executions, streaming every PatternService.StreamExecutions
+//     update as a Frame
+//   - GET /ws/agents, streaming every AgentService.StreamAgents update as
+//     a Frame
+func (g *Gateway) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/executions", g.serveExecutions)
+	mux.HandleFunc("/ws/agents", g.serveAgents)
+
+	if g.grpcWebSrv == nil {
+		return mux
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.grpcWebSrv.IsGrpcWebRequest(r) || g.grpcWebSrv.IsAcceptableGrpcCorsRequest(r) {
+			g.grpcWebSrv.ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
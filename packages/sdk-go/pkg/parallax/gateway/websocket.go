@@ -0,0 +1,156 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Frame is the parallax-js compatible envelope every WebSocket message is
+// sent in: Type identifies which stream a dashboard subscribed to, Seq is
+// a per-connection monotonically increasing counter clients can use to
+// detect gaps from dropped (backpressured) frames, and Data carries the
+// JSON-encoded PatternExecution or AgentInfo.
+type Frame struct {
+	Type string          `json:"type"`
+	Seq  uint64          `json:"seq"`
+	Data json.RawMessage `json:"data"`
+}
+
+const (
+	frameTypeExecution = "execution"
+	frameTypeAgent     = "agent"
+)
+
+// serveExecutions upgrades the request to a WebSocket and relays every
+// PatternService.StreamExecutions update as a Frame until the client
+// disconnects or the stream ends.
+func (g *Gateway) serveExecutions(w http.ResponseWriter, r *http.Request) {
+	ch, err := g.client.Patterns().StreamExecutions(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	conn, ok := g.upgrade(w, r)
+	if !ok {
+		return
+	}
+	defer conn.Close()
+
+	g.relay(r.Context(), conn, frameTypeExecution, func() (interface{}, bool) {
+		execution, ok := <-ch
+		return execution, ok
+	})
+}
+
+// serveAgents upgrades the request to a WebSocket and relays every
+// AgentService.StreamAgents update as a Frame until the client disconnects
+// or the stream ends.
+func (g *Gateway) serveAgents(w http.ResponseWriter, r *http.Request) {
+	ch, err := g.client.Agents().StreamAgents(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	conn, ok := g.upgrade(w, r)
+	if !ok {
+		return
+	}
+	defer conn.Close()
+
+	g.relay(r.Context(), conn, frameTypeAgent, func() (interface{}, bool) {
+		agent, ok := <-ch
+		return agent, ok
+	})
+}
+
+// upgrade promotes an HTTP request to a WebSocket connection sized per the
+// gateway's configured buffer limits, rejecting origins outside the
+// allowlist.
+func (g *Gateway) upgrade(w http.ResponseWriter, r *http.Request) (*websocket.Conn, bool) {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  g.maxRequestBodyBufferSize,
+		WriteBufferSize: g.maxRespBodyBufferSize,
+		CheckOrigin:     func(r *http.Request) bool { return g.isAllowedOrigin(r.Header.Get("Origin")) },
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		g.logger.Warn("websocket upgrade failed", zap.Error(err))
+		return nil, false
+	}
+
+	conn.SetReadLimit(int64(g.maxRequestBodyBufferSize))
+	return conn, true
+}
+
+// relay pumps values from next through a bounded, per-connection buffer and
+// writes each as a frameType Frame, dropping the oldest queued frame rather
+// than blocking the upstream channel when the client falls behind. It
+// returns once ctx is done, next reports no more values, or a write fails.
+func (g *Gateway) relay(ctx context.Context, conn *websocket.Conn, frameType string, next func() (interface{}, bool)) {
+	type queued struct {
+		seq   uint64
+		value interface{}
+	}
+	queue := make(chan queued, g.backpressureBufferSize)
+
+	go func() {
+		defer close(queue)
+		var seq uint64
+		for {
+			value, ok := next()
+			if !ok {
+				return
+			}
+			seq++
+			item := queued{seq: seq, value: value}
+			select {
+			case queue <- item:
+			default:
+				// Drop the oldest queued frame to make room rather than
+				// block the upstream Stream/StreamAgents channel.
+				select {
+				case <-queue:
+				default:
+				}
+				select {
+				case queue <- item:
+				default:
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item, ok := <-queue:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(item.value)
+			if err != nil {
+				g.logger.Warn("failed to marshal frame payload", zap.Error(err))
+				continue
+			}
+			if len(data) > g.maxRespBodyBufferSize {
+				g.logger.Warn("dropping frame over max response size", zap.Int("size", len(data)), zap.Int("max", g.maxRespBodyBufferSize))
+				continue
+			}
+			if err := conn.WriteJSON(Frame{Type: frameType, Seq: item.seq, Data: data}); err != nil {
+				g.logger.Warn("websocket write failed", zap.Error(err))
+				return
+			}
+		}
+	}
+}
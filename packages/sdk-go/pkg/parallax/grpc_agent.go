@@ -3,18 +3,28 @@ package parallax
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -59,38 +69,406 @@ type HealthStatus struct {
 	Message string
 }
 
+// StreamingAnalyzer is implemented by agents that can emit partial
+// AnalyzeResults as analysis progresses (e.g. one per file or function)
+// instead of a single result at the end. GrpcAgent.StreamAnalyze checks
+// for this interface and forwards each partial as its own
+// ConfidenceResult; agents that don't implement it fall back to a single
+// Analyze call, as before.
+//
+// StreamEmitAnalyzer is preferred when an agent implements both: a
+// callback-driven emit naturally propagates stream.Send backpressure (the
+// callback returns the Send error) back into the analyzer's own loop,
+// where a channel has no way to signal "the reader stopped" other than
+// the analyzer noticing ctx was cancelled.
+type StreamingAnalyzer interface {
+	AnalyzeStream(ctx context.Context, task string, data interface{}) (<-chan *AnalyzeResult, error)
+}
+
+// StreamEmitAnalyzer is implemented by agents that push partial
+// AnalyzeResults through emit as analysis progresses, rather than through
+// a StreamingAnalyzer channel. GrpcAgent.StreamAnalyze calls emit once per
+// partial result, assigns each the next ConfidenceResult.Sequence, and
+// sets Final on whichever one turns out to be the last (it isn't known
+// until StreamAnalyze returns, so forwarding is delayed by one chunk).
+// emit returns the stream.Send error verbatim, so an analyzer that checks
+// it can abort a long-running computation instead of continuing to
+// produce chunks nobody can receive.
+type StreamEmitAnalyzer interface {
+	StreamAnalyze(ctx context.Context, task string, data interface{}, emit func(*AnalyzeResult) error) error
+}
+
+// ServeAgentOptions accumulates the optional recovery, metrics, and tracing
+// layers NewGrpcAgent installs around the gRPC server, alongside the
+// panic-isolation and instrumentation ParallaxAgent.Serve gets by default.
+// NewGrpcAgent starts from defaultServeAgentOptions, which installs
+// recovery with DefaultRecoveryHandler's plain-log equivalent and leaves
+// metrics/tracing disabled; the zero value installs no recovery.
+type ServeAgentOptions struct {
+	recovery    func(p interface{}) error
+	metricsReg  *prometheus.Registry
+	metricsAddr string
+	tracer      trace.TracerProvider
+	backoff     BackoffConfig
+	retryPolicy *AnalyzeRetryPolicy
+	tls         GrpcTLSConfig
+	perRPCCreds credentials.PerRPCCredentials
+
+	keepaliveServer      keepalive.ServerParameters
+	keepaliveEnforcement keepalive.EnforcementPolicy
+	keepaliveClient      keepalive.ClientParameters
+	maxRecvMsgSize       int
+	maxSendMsgSize       int
+	maxConcurrentStreams uint32
+}
+
+// ServeAgentOption configures a ServeAgentOptions. Options are applied in
+// the order passed to NewGrpcAgent/ServeAgent.
+type ServeAgentOption func(*ServeAgentOptions)
+
+// defaultServeAgentOptions returns the options NewGrpcAgent starts from
+// before applying any ServeAgentOption: panic recovery enabled with
+// defaultRecoveryHandler, DefaultBackoffConfig for registration retries,
+// metrics, tracing, and transparent Analyze retry all disabled, and
+// keepalive tuned so a half-open TCP connection between an agent and the
+// registry doesn't linger indefinitely (see WithKeepaliveParams,
+// WithClientKeepaliveParams).
+func defaultServeAgentOptions() ServeAgentOptions {
+	return ServeAgentOptions{
+		recovery: defaultRecoveryHandler,
+		backoff:  DefaultBackoffConfig(),
+		keepaliveServer: keepalive.ServerParameters{
+			Time:              30 * time.Second,
+			Timeout:           10 * time.Second,
+			MaxConnectionIdle: 5 * time.Minute,
+		},
+		keepaliveEnforcement: keepalive.EnforcementPolicy{
+			MinTime:             10 * time.Second,
+			PermitWithoutStream: true,
+		},
+		keepaliveClient: keepalive.ClientParameters{
+			Time:                30 * time.Second,
+			Timeout:             10 * time.Second,
+			PermitWithoutStream: true,
+		},
+	}
+}
+
+// defaultRecoveryHandler logs the panic value and stack trace via the
+// standard logger and returns codes.Internal, never leaking the panic
+// value itself to the caller. Unlike interceptors.go's
+// DefaultRecoveryHandler, it uses the plain "log" package this file
+// already depends on rather than zap, since GrpcAgent has no logger of
+// its own.
+func defaultRecoveryHandler(p interface{}) error {
+	log.Printf("recovered from panic in gRPC handler: %v\n%s", p, debug.Stack())
+	return status.Error(codes.Internal, "internal error")
+}
+
+// WithRecovery installs handler as the panic-recovery callback for both
+// the unary and streaming path, converting a panic inside Analyze or
+// CheckHealth into a codes.Internal error instead of crashing the
+// process. A nil handler disables recovery entirely.
+func WithRecovery(handler func(p interface{}) error) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.recovery = handler
+	}
+}
+
+// WithMetrics registers the SDK's gRPC collectors on registry and has
+// Serve start a /metrics HTTP sidecar on addr (default ":9090" when addr
+// is empty).
+func WithMetrics(registry *prometheus.Registry, addr string) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.metricsReg = registry
+		o.metricsAddr = addr
+	}
+}
+
+// WithTracer installs tp as the TracerProvider behind the gRPC server's
+// otelgrpc stats handler, so spans from the control plane's pattern
+// execution (propagated over standard gRPC metadata, and via
+// traceMetadataUnaryInterceptor/traceMetadataStreamInterceptor for the
+// legacy ExecuteOptions.TraceID field)
+// are stitched to agent-side spans instead of starting a new trace.
+func WithTracer(tp trace.TracerProvider) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.tracer = tp
+	}
+}
+
+// WithBackoff overrides the exponential-backoff-with-jitter delay used for
+// initial registration retries (Serve), re-registration after a failed
+// lease renewal, and reconnecting to the registry. Defaults to
+// DefaultBackoffConfig.
+func WithBackoff(cfg BackoffConfig) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.backoff = cfg
+	}
+}
+
+// WithRetryPolicy installs policy so GrpcAgent.Analyze transparently
+// retries a failed call in-process whenever policy.IsIdempotent reports
+// the task safe to retry and the failure wasn't a PerformedIOError.
+// Without this option, Analyze surfaces the agent's first failure as-is.
+func WithRetryPolicy(policy AnalyzeRetryPolicy) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.retryPolicy = &policy
+	}
+}
+
+// WithTLS installs cfg as the transport security for both the embedded
+// server and outbound registry dials; see GrpcTLSConfig. Any field left
+// empty falls back to PARALLAX_TLS_CA/PARALLAX_TLS_CERT/PARALLAX_TLS_KEY,
+// and Serve fails outright rather than silently dialing insecure if the
+// result is only partially configured.
+func WithTLS(cfg GrpcTLSConfig) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.tls = cfg
+	}
+}
+
+// WithPerRPCCredentials attaches creds (e.g. NewBearerTokenCredentials, or
+// a caller's own JWT-refreshing implementation) to every outbound
+// registry RPC via grpc.WithPerRPCCredentials, letting agents authenticate
+// to a protected control plane. Falls back to a NewBearerTokenCredentials
+// built from PARALLAX_AUTH_TOKEN when unset.
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.perRPCCreds = creds
+	}
+}
+
+// WithKeepaliveParams overrides the embedded gRPC server's keepalive
+// ping/timeout/idle behavior (default: Time 30s, Timeout 10s,
+// MaxConnectionIdle 5m) and the policy enforced against clients that ping
+// too aggressively (default: MinTime 10s, PermitWithoutStream true, so a
+// long-lived agent connection with no active Analyze stream is still
+// pinged rather than torn down).
+func WithKeepaliveParams(server keepalive.ServerParameters, enforcement keepalive.EnforcementPolicy) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.keepaliveServer = server
+		o.keepaliveEnforcement = enforcement
+	}
+}
+
+// WithClientKeepaliveParams overrides the keepalive behavior of outbound
+// registry dials (default: Time 30s, Timeout 10s, PermitWithoutStream
+// true), so a half-open connection to a registry that silently dropped
+// the agent is detected instead of lingering until the next RPC attempt.
+func WithClientKeepaliveParams(client keepalive.ClientParameters) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.keepaliveClient = client
+	}
+}
+
+// WithMaxMessageSize overrides the embedded gRPC server's and outbound
+// registry dials' max message size for, respectively, received and sent
+// messages. Zero leaves gRPC's built-in default (4 MiB) in place; set
+// this when Analyze payloads can exceed it.
+func WithMaxMessageSize(recv, send int) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.maxRecvMsgSize = recv
+		o.maxSendMsgSize = send
+	}
+}
+
+// WithMaxConcurrentStreams bounds the number of concurrent streams
+// (Analyze/StreamAnalyze calls) the embedded gRPC server accepts per
+// client connection. Zero leaves gRPC's built-in default (effectively
+// unbounded) in place.
+func WithMaxConcurrentStreams(n uint32) ServeAgentOption {
+	return func(o *ServeAgentOptions) {
+		o.maxConcurrentStreams = n
+	}
+}
+
+// TraceMetadataKey is the incoming gRPC metadata key
+// traceMetadataUnaryInterceptor/traceMetadataStreamInterceptor read
+// ExecuteOptions.TraceID from, for callers that haven't adopted full
+// otelgrpc context propagation yet.
+const TraceMetadataKey = "trace_id"
+
+// attachTraceMetadata sets TraceMetadataKey's value, if present in ctx's
+// incoming metadata, as an attribute on the span otelgrpc's stats handler
+// already opened for this RPC.
+func attachTraceMetadata(ctx context.Context) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return
+	}
+	values := md.Get(TraceMetadataKey)
+	if len(values) == 0 {
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(TraceMetadataKey, values[0]))
+}
+
+func traceMetadataUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		attachTraceMetadata(ctx)
+		return next(ctx, req)
+	}
+}
+
+func traceMetadataStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		attachTraceMetadata(ss.Context())
+		return next(srv, ss)
+	}
+}
+
 // GrpcAgent wraps an Agent with gRPC server capabilities
 type GrpcAgent struct {
 	confidence.UnimplementedConfidenceAgentServer
-	
-	agent        Agent
-	server       *grpc.Server
-	port         int
-	registryAddr string
-	leaseID      string
-	stopCh       chan struct{}
-	wg           sync.WaitGroup
-	mu           sync.Mutex
+
+	agent         Agent
+	server        *grpc.Server
+	port          int
+	registryAddrs []string
+	balancer      *registryBalancer
+	config        *configState
+	leaseID       string
+	stopCh        chan struct{}
+	wg            sync.WaitGroup
+	mu            sync.Mutex
+
+	opts    ServeAgentOptions
+	metrics *Metrics
+
+	tracerOnce     sync.Once
+	tracerImpl     trace.Tracer
+	tracerShutdown func(context.Context) error
+
+	tlsClientCreds *grpcTLSCredentials
+	tlsServerCreds *grpcTLSCredentials
 }
 
-// NewGrpcAgent creates a new gRPC-enabled agent
-func NewGrpcAgent(agent Agent) *GrpcAgent {
-	registryAddr := os.Getenv("PARALLAX_REGISTRY")
-	if registryAddr == "" {
-		registryAddr = "localhost:50051"
+// NewGrpcAgent creates a new gRPC-enabled agent. PARALLAX_REGISTRY may name
+// a single registry address or a comma-separated list; Register/Renew/
+// Unregister are pinned to one healthy endpoint at a time via a
+// registryBalancer, failing over to the next on error (see
+// registryBalancer). opts configure the optional recovery/metrics/tracing/
+// TLS/auth chain Serve installs; see WithRecovery, WithMetrics, WithTracer,
+// WithTLS, and WithPerRPCCredentials. The registryBalancer itself isn't
+// built until Serve, since that's where a misconfigured WithTLS fails
+// loudly instead of silently dialing insecure.
+func NewGrpcAgent(agent Agent, opts ...ServeAgentOption) *GrpcAgent {
+	registryAddrs := os.Getenv("PARALLAX_REGISTRY")
+	if registryAddrs == "" {
+		registryAddrs = "localhost:50051"
+	}
+
+	o := defaultServeAgentOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.tls = grpcTLSConfigFromEnv(o.tls)
+	if o.perRPCCreds == nil {
+		if token := os.Getenv("PARALLAX_AUTH_TOKEN"); token != "" {
+			o.perRPCCreds = NewBearerTokenCredentials(token)
+		}
 	}
 
+	config := newConfigState()
+	config.seedTracingFromEnv()
+
 	return &GrpcAgent{
-		agent:        agent,
-		registryAddr: registryAddr,
-		stopCh:       make(chan struct{}),
+		agent:         agent,
+		registryAddrs: strings.Split(registryAddrs, ","),
+		config:        config,
+		stopCh:        make(chan struct{}),
+		opts:          o,
 	}
 }
 
-// Serve starts the gRPC server and registers with the control plane
+// Serve starts the gRPC server and registers with the control plane. The
+// server always installs keepalive and message-size/concurrency limits
+// (see WithKeepaliveParams, WithClientKeepaliveParams, WithMaxMessageSize,
+// WithMaxConcurrentStreams), then, as configured: (1) TLS transport
+// credentials (see WithTLS), (2) a panic-recovery interceptor converting
+// panics into codes.Internal errors (see WithRecovery), (3) a metrics
+// interceptor recording in-flight requests, latency, and error class per
+// method when WithMetrics was passed, and (4) an otelgrpc stats handler
+// stitching spans from the control plane when WithTracer was passed.
+// Returns an error immediately if WithTLS's configuration is only
+// partially set, rather than silently falling back to an insecure
+// server/dial.
 func (g *GrpcAgent) Serve(port int) error {
+	if err := g.opts.tls.validate(); err != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
+	clientSetOpts := ClientSetOptions{}
+	if !g.opts.tls.empty() {
+		creds, err := g.opts.tls.clientCredentials()
+		if err != nil {
+			return fmt.Errorf("failed to build registry TLS credentials: %w", err)
+		}
+		g.tlsClientCreds = creds
+		clientSetOpts.TransportCredentials = creds
+	}
+	if g.opts.perRPCCreds != nil {
+		clientSetOpts.DialOptions = append(clientSetOpts.DialOptions, grpc.WithPerRPCCredentials(g.opts.perRPCCreds))
+	}
+	clientSetOpts.DialOptions = append(clientSetOpts.DialOptions, grpc.WithKeepaliveParams(g.opts.keepaliveClient))
+	if g.opts.maxRecvMsgSize > 0 {
+		clientSetOpts.DialOptions = append(clientSetOpts.DialOptions, grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(g.opts.maxRecvMsgSize)))
+	}
+	if g.opts.maxSendMsgSize > 0 {
+		clientSetOpts.DialOptions = append(clientSetOpts.DialOptions, grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(g.opts.maxSendMsgSize)))
+	}
+	g.balancer = newRegistryBalancer(g.registryAddrs, NewRegistryClientSet(clientSetOpts))
+
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+
+	if g.opts.recovery != nil {
+		unary = append(unary, recoveryUnaryInterceptor(func(ctx context.Context, p interface{}) error { return g.opts.recovery(p) }))
+		stream = append(stream, recoveryStreamInterceptor(func(ctx context.Context, p interface{}) error { return g.opts.recovery(p) }))
+	}
+
+	if g.opts.metricsReg != nil {
+		g.metrics = NewMetrics(g.opts.metricsReg)
+		unary = append(unary, g.metrics.unaryServerInterceptor())
+		stream = append(stream, g.metrics.streamServerInterceptor())
+	}
+
+	grpcOpts := []grpc.ServerOption{
+		grpc.KeepaliveParams(g.opts.keepaliveServer),
+		grpc.KeepaliveEnforcementPolicy(g.opts.keepaliveEnforcement),
+	}
+	if g.opts.maxRecvMsgSize > 0 {
+		grpcOpts = append(grpcOpts, grpc.MaxRecvMsgSize(g.opts.maxRecvMsgSize))
+	}
+	if g.opts.maxSendMsgSize > 0 {
+		grpcOpts = append(grpcOpts, grpc.MaxSendMsgSize(g.opts.maxSendMsgSize))
+	}
+	if g.opts.maxConcurrentStreams > 0 {
+		grpcOpts = append(grpcOpts, grpc.MaxConcurrentStreams(g.opts.maxConcurrentStreams))
+	}
+	if !g.opts.tls.empty() {
+		serverCreds, err := g.opts.tls.serverCredentials()
+		if err != nil {
+			return fmt.Errorf("failed to build server TLS credentials: %w", err)
+		}
+		g.tlsServerCreds = serverCreds
+		grpcOpts = append(grpcOpts, grpc.Creds(serverCreds))
+	}
+	if g.opts.tracer != nil {
+		grpcOpts = append(grpcOpts, grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(g.opts.tracer))))
+		unary = append(unary, traceMetadataUnaryInterceptor())
+		stream = append(stream, traceMetadataStreamInterceptor())
+	}
+	if len(unary) > 0 {
+		grpcOpts = append(grpcOpts, grpc.ChainUnaryInterceptor(unary...))
+	}
+	if len(stream) > 0 {
+		grpcOpts = append(grpcOpts, grpc.ChainStreamInterceptor(stream...))
+	}
+
 	// Create gRPC server
-	g.server = grpc.NewServer()
+	g.server = grpc.NewServer(grpcOpts...)
 	confidence.RegisterConfidenceAgentServer(g.server, g)
 
 	// Listen on port
@@ -112,12 +490,60 @@ func (g *GrpcAgent) Serve(port int) error {
 		}
 	}()
 
-	// Register with control plane
-	if err := g.register(); err != nil {
-		log.Printf("Failed to register with control plane: %v", err)
-		// Continue running even if registration fails
+	if g.metrics != nil {
+		g.wg.Add(1)
+		go func() {
+			defer g.wg.Done()
+			if err := g.serveMetrics(); err != nil {
+				log.Printf("Failed to serve metrics: %v", err)
+			}
+		}()
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		g.balancer.runProbeLoop(g.stopCh)
+	}()
+
+	// Register with control plane. Runs in the background, retrying with
+	// backoff (see BackoffConfig/WithBackoff) until it succeeds or the
+	// agent is stopped, so a registry outage at startup doesn't block the
+	// server from serving in the meantime.
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := g.register(); err != nil {
+			log.Printf("Giving up on registration: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// serveMetrics runs the embedded /metrics HTTP sidecar until stopCh is
+// closed. Mirrors ParallaxAgent.serveMetrics's pattern, tied to
+// GrpcAgent's stopCh/wg instead of a context.
+func (g *GrpcAgent) serveMetrics() error {
+	addr := g.opts.metricsAddr
+	if addr == "" {
+		addr = ":9090"
 	}
 
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", g.metrics.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-g.stopCh
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
 	return nil
 }
 
@@ -132,25 +558,90 @@ func (g *GrpcAgent) Stop() error {
 
 	// Stop gRPC server
 	g.server.GracefulStop()
-	
+
 	// Wait for goroutines
 	g.wg.Wait()
 
+	if err := g.balancer.clientSet.Close(); err != nil {
+		log.Printf("Failed to close registry connections: %v", err)
+	}
+
+	if g.tlsClientCreds != nil {
+		if err := g.tlsClientCreds.Close(); err != nil {
+			log.Printf("Failed to close registry TLS credentials: %v", err)
+		}
+	}
+	if g.tlsServerCreds != nil {
+		if err := g.tlsServerCreds.Close(); err != nil {
+			log.Printf("Failed to close server TLS credentials: %v", err)
+		}
+	}
+
+	if g.tracerShutdown != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := g.tracerShutdown(shutdownCtx); err != nil {
+			log.Printf("Failed to shut down tracer provider: %v", err)
+		}
+	}
+
 	return nil
 }
 
-// register registers the agent with the control plane
+// register performs the agent's initial registration with the control
+// plane, retrying doRegister with backoff until it succeeds or the agent
+// is stopped, then starts the background loops that keep its lease
+// renewed and its pushed config (see ConfigStream, runConfigStream)
+// up to date.
 func (g *GrpcAgent) register() error {
-	conn, err := grpc.NewClient(g.registryAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err := g.registerWithRetry(); err != nil {
+		return err
+	}
+
+	g.wg.Add(1)
+	go g.renewLeaseLoop()
+
+	g.wg.Add(1)
+	go g.runConfigStream()
+
+	return nil
+}
+
+// registerWithRetry calls doRegister repeatedly, backing off per
+// g.opts.backoff between attempts (resetting to attempt 0 on the first
+// success), until it succeeds or g.stopCh closes.
+func (g *GrpcAgent) registerWithRetry() error {
+	for attempt := 0; ; attempt++ {
+		err := g.doRegister()
+		if err == nil {
+			return nil
+		}
+		if attempt == 0 {
+			log.Printf("Failed to register with control plane, retrying with backoff: %v", err)
+		}
+
+		select {
+		case <-g.stopCh:
+			return fmt.Errorf("registration aborted, agent is stopping: %w", err)
+		case <-time.After(g.opts.backoff.delay(attempt)):
+		}
+	}
+}
+
+// doRegister issues a single Register RPC against the balancer's current
+// endpoint, marking that endpoint unhealthy on failure so the next call
+// (from renewLeaseLoop or a caller retrying register) fails over. Unlike
+// register, it never spawns a renewal loop, so renewLeaseLoop can call it
+// directly to re-acquire a lease without leaking goroutines.
+func (g *GrpcAgent) doRegister() error {
+	client, addr, _, err := g.balancer.registryClient()
 	if err != nil {
-		return fmt.Errorf("failed to connect to registry: %w", err)
+		return fmt.Errorf("failed to select registry endpoint: %w", err)
 	}
-	defer conn.Close()
 
-	client := registry.NewRegistryClient(conn)
-	
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	ctx = injectOutgoingTraceContext(ctx)
 
 	req := &registry.RegisterRequest{
 		Agent: &registry.AgentRegistration{
@@ -163,66 +654,67 @@ func (g *GrpcAgent) register() error {
 
 	resp, err := client.Register(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to register: %w", err)
+		g.balancer.markUnhealthy(addr, err)
+		return fmt.Errorf("failed to register with %s: %w", addr, err)
 	}
 
 	g.mu.Lock()
 	g.leaseID = resp.LeaseId
 	g.mu.Unlock()
 
-	log.Printf("Agent %s registered with control plane, lease_id: %s", g.agent.GetID(), g.leaseID)
-
-	// Start lease renewal
-	g.wg.Add(1)
-	go g.renewLeaseLoop()
-
+	log.Printf("Agent %s registered with registry %s, lease_id: %s", g.agent.GetID(), addr, resp.LeaseId)
 	return nil
 }
 
-// renewLeaseLoop periodically renews the agent's lease
+// renewLeaseLoop periodically renews the agent's lease over the
+// balancer's shared connection, rather than opening one of its own. When
+// the balancer has failed over to a different endpoint since the last
+// tick, the old lease can't be renewed there (it belongs to a different
+// registry instance), so the loop re-registers instead, transparently
+// retrying with backoff via registerWithRetry rather than waiting out the
+// fixed 30s tick.
 func (g *GrpcAgent) renewLeaseLoop() {
 	defer g.wg.Done()
 
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
-	conn, err := grpc.NewClient(g.registryAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		log.Printf("Failed to connect for lease renewal: %v", err)
-		return
-	}
-	defer conn.Close()
-
-	client := registry.NewRegistryClient(conn)
-
 	for {
 		select {
 		case <-g.stopCh:
 			return
 		case <-ticker.C:
+			client, addr, endpointChanged, err := g.balancer.registryClient()
+			if err != nil {
+				log.Printf("No healthy registry endpoint for lease renewal: %v", err)
+				continue
+			}
+
 			g.mu.Lock()
 			leaseID := g.leaseID
 			g.mu.Unlock()
 
-			if leaseID == "" {
+			if endpointChanged || leaseID == "" {
+				if err := g.registerWithRetry(); err != nil {
+					log.Printf("Failed to re-register after registry failover: %v", err)
+				}
 				continue
 			}
 
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			req := &registry.RenewRequest{LeaseId: leaseID}
-			
-			resp, err := client.Renew(ctx, req)
+			ctx = injectOutgoingTraceContext(ctx)
+			resp, err := client.Renew(ctx, &registry.RenewRequest{LeaseId: leaseID})
 			cancel()
 
 			if err != nil {
-				log.Printf("Failed to renew lease: %v", err)
+				log.Printf("Failed to renew lease against %s: %v", addr, err)
+				g.balancer.markUnhealthy(addr, err)
 				continue
 			}
 
 			if !resp.Success {
-				log.Printf("Lease renewal failed")
-				// Try to re-register
-				if err := g.register(); err != nil {
+				log.Printf("Lease renewal rejected by %s, re-registering", addr)
+				if err := g.registerWithRetry(); err != nil {
 					log.Printf("Failed to re-register: %v", err)
 				}
 			}
@@ -233,63 +725,135 @@ func (g *GrpcAgent) renewLeaseLoop() {
 // unregister removes the agent from the control plane
 func (g *GrpcAgent) unregister() error {
 	g.mu.Lock()
-	if g.leaseID == "" {
-		g.mu.Unlock()
+	leaseID := g.leaseID
+	g.mu.Unlock()
+	if leaseID == "" {
 		return nil
 	}
-	g.mu.Unlock()
 
-	conn, err := grpc.NewClient(g.registryAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	client, addr, _, err := g.balancer.registryClient()
 	if err != nil {
-		return fmt.Errorf("failed to connect to registry: %w", err)
+		return fmt.Errorf("failed to select registry endpoint: %w", err)
 	}
-	defer conn.Close()
 
-	client := registry.NewRegistryClient(conn)
-	
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	ctx = injectOutgoingTraceContext(ctx)
 
-	req := &registry.AgentRegistration{Id: g.agent.GetID()}
-	
-	_, err = client.Unregister(ctx, req)
-	if err != nil {
-		return fmt.Errorf("failed to unregister: %w", err)
+	if _, err := client.Unregister(ctx, &registry.AgentRegistration{Id: g.agent.GetID()}); err != nil {
+		g.balancer.markUnhealthy(addr, err)
+		return fmt.Errorf("failed to unregister from %s: %w", addr, err)
 	}
 
-	log.Printf("Agent %s unregistered from control plane", g.agent.GetID())
+	log.Printf("Agent %s unregistered from registry %s", g.agent.GetID(), addr)
 	return nil
 }
 
-// Analyze implements the ConfidenceAgent.Analyze RPC
+// Analyze implements the ConfidenceAgent.Analyze RPC. A failure the agent
+// wrapped with WrapPerformedIO is surfaced as codes.Internal (unsafe to
+// retry, since side-effecting I/O already happened); every other failure
+// is surfaced as codes.Unavailable (safe to retry). When WithRetryPolicy
+// was configured and marks the task idempotent, non-PerformedIOError
+// failures are retried in-process before either is surfaced. Before any
+// of that, req.TaskDescription is checked against the control plane's
+// pushed TaskPolicy (see ConfigStream) and throttled against its
+// RateLimitConfig; a successful result's confidence is clamped to the
+// policy's floor. The call runs inside an OTel span (see startAnalyzeSpan,
+// SpanFromContext) continuing any W3C trace context the caller sent.
 func (g *GrpcAgent) Analyze(ctx context.Context, req *confidence.AgentRequest) (*confidence.ConfidenceResult, error) {
 	if req.TaskDescription == "" {
 		return nil, status.Error(codes.InvalidArgument, "task description is required")
 	}
 
+	if err := g.config.checkTask(req.TaskDescription); err != nil {
+		return nil, err
+	}
+	if !g.config.allow(req.TaskDescription) {
+		return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded for task %q", req.TaskDescription)
+	}
+
+	ctx, span := g.startAnalyzeSpan(ctx, req.TaskDescription)
+	defer span.End()
+
 	// Parse task data if provided
 	var data interface{}
 	if req.Data != nil {
 		data = req.Data.AsMap()
 	}
 
-	// Call agent's analyze method
-	result, err := g.agent.Analyze(ctx, req.TaskDescription, data)
+	result, err := g.analyzeWithRetry(ctx, req.TaskDescription, data)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "analysis failed: %v", err)
+		span.RecordError(err)
+
+		var ioErr *PerformedIOError
+		if errors.As(err, &ioErr) {
+			return nil, status.Errorf(codes.Internal, "analysis failed: %v", ioErr.Err)
+		}
+		return nil, status.Errorf(codes.Unavailable, "analysis failed: %v", err)
 	}
+	result.Confidence = g.config.clampConfidence(result.Confidence)
+	recordAnalyzeResult(span, result)
 
-	// Marshal result value
-	valueJSON, err := json.Marshal(result.Value)
+	resp, err := toConfidenceResult(g.agent.GetID(), result)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to marshal result: %v", err)
 	}
+	return resp, nil
+}
+
+// analyzeWithRetry calls Agent.Analyze once and, when g.opts.retryPolicy
+// marks task idempotent, transparently retries non-PerformedIOError
+// failures up to policy.MaxAttempts with backoff. Without a configured
+// retry policy it's a single call, unchanged from before WithRetryPolicy
+// existed.
+func (g *GrpcAgent) analyzeWithRetry(ctx context.Context, task string, data interface{}) (*AnalyzeResult, error) {
+	policy := g.opts.retryPolicy
+	if policy == nil || !policy.retryable(task) {
+		return g.agent.Analyze(ctx, task, data)
+	}
+
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	backoff := policy.backoff()
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff.delay(attempt - 1)):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		result, err := g.agent.Analyze(ctx, task, data)
+		if err == nil {
+			return result, nil
+		}
+
+		var ioErr *PerformedIOError
+		if errors.As(err, &ioErr) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// toConfidenceResult marshals result into the wire-level ConfidenceResult
+// Analyze and StreamAnalyze both send.
+func toConfidenceResult(agentID string, result *AnalyzeResult) (*confidence.ConfidenceResult, error) {
+	valueJSON, err := json.Marshal(result.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
 
-	// Build response
 	return &confidence.ConfidenceResult{
 		ValueJson:     string(valueJSON),
 		Confidence:    result.Confidence,
-		AgentId:       g.agent.GetID(),
+		AgentId:       agentID,
 		Timestamp:     timestamppb.Now(),
 		Reasoning:     result.Reasoning,
 		Uncertainties: result.Uncertainties,
@@ -297,23 +861,130 @@ func (g *GrpcAgent) Analyze(ctx context.Context, req *confidence.AgentRequest) (
 	}, nil
 }
 
-// StreamAnalyze implements the ConfidenceAgent.StreamAnalyze RPC
+// StreamAnalyze implements the ConfidenceAgent.StreamAnalyze RPC. Agents
+// implementing StreamEmitAnalyzer (preferred) or StreamingAnalyzer have
+// each of their partial AnalyzeResults forwarded as its own
+// ConfidenceResult, numbered by a monotonically increasing Sequence with
+// Final set on the last one; every other agent falls back to a single
+// Analyze call, as before, with Final set on that one message.
 func (g *GrpcAgent) StreamAnalyze(req *confidence.AgentRequest, stream grpc.ServerStreamingServer[confidence.ConfidenceResult]) error {
-	// For now, just execute once and send result
-	// TODO: Implement proper streaming
-	
+	if req.TaskDescription == "" {
+		return status.Error(codes.InvalidArgument, "task description is required")
+	}
+
+	var data interface{}
+	if req.Data != nil {
+		data = req.Data.AsMap()
+	}
+
+	if emitter, ok := g.agent.(StreamEmitAnalyzer); ok {
+		return g.streamAnalyzeEmit(stream, emitter, req.TaskDescription, data)
+	}
+
+	if streaming, ok := g.agent.(StreamingAnalyzer); ok {
+		return g.streamAnalyzeChannel(stream, streaming, req.TaskDescription, data)
+	}
+
 	resp, err := g.Analyze(stream.Context(), req)
 	if err != nil {
 		return err
 	}
-
+	resp.Final = true
 	return stream.Send(resp)
 }
 
-// GetCapabilities implements the ConfidenceAgent.GetCapabilities RPC
+// streamAnalyzeEmit drives a StreamEmitAnalyzer, forwarding each emitted
+// AnalyzeResult as its own ConfidenceResult. Forwarding is delayed by one
+// chunk so the one that turns out to be last can be marked Final; if
+// stream.Send fails, the analyzer's ctx is cancelled so it can abort
+// instead of continuing to produce chunks nobody can receive.
+func (g *GrpcAgent) streamAnalyzeEmit(stream grpc.ServerStreamingServer[confidence.ConfidenceResult], emitter StreamEmitAnalyzer, task string, data interface{}) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	var seq int64
+	var pending *confidence.ConfidenceResult
+
+	emit := func(result *AnalyzeResult) error {
+		resp, err := toConfidenceResult(g.agent.GetID(), result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal result: %w", err)
+		}
+		resp.Sequence = seq
+		seq++
+
+		if pending != nil {
+			if err := stream.Send(pending); err != nil {
+				cancel()
+				return err
+			}
+		}
+		pending = resp
+		return nil
+	}
+
+	if err := emitter.StreamAnalyze(ctx, task, data, emit); err != nil {
+		return status.Errorf(codes.Internal, "streaming analysis failed: %v", err)
+	}
+
+	if pending != nil {
+		pending.Final = true
+		if err := stream.Send(pending); err != nil {
+			cancel()
+			return err
+		}
+	}
+	return nil
+}
+
+// streamAnalyzeChannel drives a StreamingAnalyzer's channel the same way
+// streamAnalyzeEmit drives a callback: forwarding is delayed by one chunk
+// so the last one can be marked Final, and a failed stream.Send cancels
+// ctx so the analyzer can stop producing into a channel nobody drains.
+func (g *GrpcAgent) streamAnalyzeChannel(stream grpc.ServerStreamingServer[confidence.ConfidenceResult], streaming StreamingAnalyzer, task string, data interface{}) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	results, err := streaming.AnalyzeStream(ctx, task, data)
+	if err != nil {
+		return status.Errorf(codes.Internal, "streaming analysis failed: %v", err)
+	}
+
+	var seq int64
+	var pending *confidence.ConfidenceResult
+	for result := range results {
+		resp, err := toConfidenceResult(g.agent.GetID(), result)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to marshal result: %v", err)
+		}
+		resp.Sequence = seq
+		seq++
+
+		if pending != nil {
+			if err := stream.Send(pending); err != nil {
+				cancel()
+				return err
+			}
+		}
+		pending = resp
+	}
+
+	if pending != nil {
+		pending.Final = true
+		if err := stream.Send(pending); err != nil {
+			cancel()
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCapabilities implements the ConfidenceAgent.GetCapabilities RPC,
+// applying the control plane's pushed CapabilityOverrides (see
+// ConfigStream) on top of the agent's own capability list.
 func (g *GrpcAgent) GetCapabilities(ctx context.Context, req *emptypb.Empty) (*confidence.Capabilities, error) {
 	return &confidence.Capabilities{
-		Capabilities: g.agent.GetCapabilities(),
+		Capabilities: g.config.applyCapabilityOverrides(g.agent.GetCapabilities()),
 	}, nil
 }
 
@@ -341,10 +1012,12 @@ func (g *GrpcAgent) HealthCheck(ctx context.Context, req *emptypb.Empty) (*confi
 	}, nil
 }
 
-// ServeAgent is a convenience function to serve an agent
-func ServeAgent(agent Agent, port int) error {
-	grpcAgent := NewGrpcAgent(agent)
-	
+// ServeAgent is a convenience function to serve an agent. opts configure
+// the optional recovery/metrics/tracing interceptor chain; see
+// WithRecovery, WithMetrics, and WithTracer.
+func ServeAgent(agent Agent, port int, opts ...ServeAgentOption) error {
+	grpcAgent := NewGrpcAgent(agent, opts...)
+
 	if err := grpcAgent.Serve(port); err != nil {
 		return err
 	}
@@ -0,0 +1,324 @@
+package parallax
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"google.golang.org/grpc/credentials"
+)
+
+// GrpcTLSConfig configures transport security for both sides of GrpcAgent's
+// traffic: the embedded server accepting peer-agent/control-plane calls,
+// and the outbound dials register/renewLeaseLoop/unregister make to the
+// registry through its registryBalancer. It's deliberately simpler than
+// TLSConfig (Client's dial-only config, with SPIFFE support via a
+// zap.Logger): GrpcAgent has no logger of its own and needs to configure a
+// server identity as well as a client one. Both sides still get the same
+// hot-reload guarantee TLSConfig gives Client: clientCredentials/
+// serverCredentials watch their cert/key files via grpcCertWatcher, so a
+// certificate rotated onto disk (e.g. by runCredentialLifecycle) takes
+// effect on the next handshake without restarting the agent.
+//
+// The zero value means "no TLS", preserving GrpcAgent's original insecure
+// behavior. Leaving fields empty lets PARALLAX_TLS_CA/PARALLAX_TLS_CERT/
+// PARALLAX_TLS_KEY seed them (see grpcTLSConfigFromEnv); Serve fails with
+// an error rather than silently dropping to insecure when the result is
+// only partially configured (see validate).
+type GrpcTLSConfig struct {
+	// CAFile is a PEM bundle of CAs to verify the registry's server
+	// certificate against, and (when RequireClientCert is set) to verify
+	// peer client certificates against on the embedded server.
+	CAFile string
+
+	// ClientCertFile/ClientKeyFile are presented as this agent's client
+	// certificate on outbound registry dials.
+	ClientCertFile string
+	ClientKeyFile  string
+
+	// ServerCertFile/ServerKeyFile are presented as this agent's server
+	// certificate by the embedded gRPC server.
+	ServerCertFile string
+	ServerKeyFile  string
+
+	// RequireClientCert has the embedded server request and verify a
+	// client certificate from every peer, rejecting the handshake if one
+	// isn't presented or doesn't chain to CAFile.
+	RequireClientCert bool
+}
+
+// grpcTLSConfigFromEnv seeds cfg's empty fields from PARALLAX_TLS_CA,
+// PARALLAX_TLS_CERT, and PARALLAX_TLS_KEY, using the same certificate/key
+// pair for both the outbound dial and the embedded server unless
+// ClientCertFile/ClientKeyFile or ServerCertFile/ServerKeyFile were
+// already set explicitly.
+func grpcTLSConfigFromEnv(cfg GrpcTLSConfig) GrpcTLSConfig {
+	ca := os.Getenv("PARALLAX_TLS_CA")
+	cert := os.Getenv("PARALLAX_TLS_CERT")
+	key := os.Getenv("PARALLAX_TLS_KEY")
+
+	if cfg.CAFile == "" {
+		cfg.CAFile = ca
+	}
+	if cfg.ClientCertFile == "" {
+		cfg.ClientCertFile = cert
+	}
+	if cfg.ClientKeyFile == "" {
+		cfg.ClientKeyFile = key
+	}
+	if cfg.ServerCertFile == "" {
+		cfg.ServerCertFile = cert
+	}
+	if cfg.ServerKeyFile == "" {
+		cfg.ServerKeyFile = key
+	}
+	return cfg
+}
+
+// empty reports whether cfg has no TLS material configured at all, i.e.
+// GrpcAgent should behave exactly as it did before GrpcTLSConfig existed:
+// an insecure dial out and a bare grpc.NewServer.
+func (cfg GrpcTLSConfig) empty() bool {
+	return cfg == (GrpcTLSConfig{})
+}
+
+// validate reports a partial configuration that would otherwise silently
+// fall back to insecure: a cert without its key (or vice versa) for
+// either role, or RequireClientCert set without a CA to verify against.
+func (cfg GrpcTLSConfig) validate() error {
+	if (cfg.ClientCertFile == "") != (cfg.ClientKeyFile == "") {
+		return fmt.Errorf("TLS client cert and key must both be set or both be empty")
+	}
+	if (cfg.ServerCertFile == "") != (cfg.ServerKeyFile == "") {
+		return fmt.Errorf("TLS server cert and key must both be set or both be empty")
+	}
+	if cfg.RequireClientCert && cfg.CAFile == "" {
+		return fmt.Errorf("RequireClientCert requires CAFile to verify client certificates against")
+	}
+	return nil
+}
+
+// grpcTLSCredentials is the credentials.TransportCredentials
+// clientCredentials/serverCredentials hand back, plus the grpcCertWatcher
+// (if any) backing their hot-reload, so the caller can close it on
+// shutdown. Mirrors tls.go's tlsCredentials for Client's dial-side
+// credentials.
+type grpcTLSCredentials struct {
+	credentials.TransportCredentials
+	closers []func() error
+}
+
+// Close releases any grpcCertWatcher backing these credentials. Safe to
+// call on a value with no watcher (e.g. a CA-only config).
+func (c *grpcTLSCredentials) Close() error {
+	var firstErr error
+	for _, closer := range c.closers {
+		if err := closer(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// clientCredentials builds the credentials.TransportCredentials GrpcAgent's
+// RegistryClientSet dials out with. When ClientCertFile/ClientKeyFile are
+// set, it watches them for changes (see grpcCertWatcher) so a credential
+// rotated onto disk — by runCredentialLifecycle, or any other external
+// rotation — takes effect on the connection's next handshake without a
+// restart, the same guarantee tls.go's buildTLSCredentials gives Client.
+func (cfg GrpcTLSConfig) clientCredentials() (*grpcTLSCredentials, error) {
+	tlsConf := &tls.Config{}
+	creds := &grpcTLSCredentials{}
+
+	if cfg.CAFile != "" {
+		pool, err := cfg.caPool()
+		if err != nil {
+			return nil, err
+		}
+		tlsConf.RootCAs = pool
+	}
+	if cfg.ClientCertFile != "" {
+		watcher, err := newGrpcCertWatcher(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConf.GetClientCertificate = watcher.getClientCertificate
+		creds.closers = append(creds.closers, watcher.Close)
+	}
+
+	creds.TransportCredentials = credentials.NewTLS(tlsConf)
+	return creds, nil
+}
+
+// serverCredentials builds the credentials.TransportCredentials the
+// embedded gRPC server is constructed with. Like clientCredentials, it
+// watches ServerCertFile/ServerKeyFile for changes so a rotated server
+// certificate is presented on every subsequent handshake without
+// restarting the server.
+func (cfg GrpcTLSConfig) serverCredentials() (*grpcTLSCredentials, error) {
+	if cfg.ServerCertFile == "" {
+		return nil, fmt.Errorf("TLS requires a server certificate/key (ServerCertFile/ServerKeyFile or PARALLAX_TLS_CERT/PARALLAX_TLS_KEY)")
+	}
+
+	watcher, err := newGrpcCertWatcher(cfg.ServerCertFile, cfg.ServerKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	tlsConf := &tls.Config{GetCertificate: watcher.getCertificate}
+
+	if cfg.RequireClientCert {
+		pool, err := cfg.caPool()
+		if err != nil {
+			watcher.Close()
+			return nil, err
+		}
+		tlsConf.ClientCAs = pool
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return &grpcTLSCredentials{
+		TransportCredentials: credentials.NewTLS(tlsConf),
+		closers:              []func() error{watcher.Close},
+	}, nil
+}
+
+// grpcCertWatcher holds the most recently loaded certificate for a
+// cert/key file pair and reloads it whenever either file changes, the same
+// pattern tls.go's certWatcher uses for Client. GrpcTLSConfig has no
+// logger of its own (see its doc comment), so reload failures/successes
+// are reported via the log package, matching enroll.go and grpc_agent.go's
+// own logging.
+type grpcCertWatcher struct {
+	certFile string
+	keyFile  string
+
+	cert    atomic.Pointer[tls.Certificate]
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+func newGrpcCertWatcher(certFile, keyFile string) (*grpcCertWatcher, error) {
+	cw := &grpcCertWatcher{certFile: certFile, keyFile: keyFile, done: make(chan struct{})}
+	if err := cw.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start certificate watcher: %w", err)
+	}
+
+	// Watch the containing directories rather than the files themselves:
+	// most tools rotate a certificate by writing a new file and renaming
+	// it over the old one, which fsnotify only reports as an event on the
+	// directory, not the (now-replaced) file.
+	for _, dir := range uniqueDirs(certFile, keyFile) {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	cw.watcher = watcher
+	go cw.run()
+	return cw, nil
+}
+
+func (cw *grpcCertWatcher) reload() error {
+	cert, err := tls.LoadX509KeyPair(cw.certFile, cw.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	cw.cert.Store(&cert)
+	return nil
+}
+
+func (cw *grpcCertWatcher) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return cw.cert.Load(), nil
+}
+
+func (cw *grpcCertWatcher) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return cw.cert.Load(), nil
+}
+
+func (cw *grpcCertWatcher) run() {
+	for {
+		select {
+		case <-cw.done:
+			return
+		case event, ok := <-cw.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(cw.certFile) && filepath.Clean(event.Name) != filepath.Clean(cw.keyFile) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := cw.reload(); err != nil {
+				log.Printf("failed to reload certificate %s: %v", cw.certFile, err)
+				continue
+			}
+			log.Printf("reloaded certificate %s", cw.certFile)
+		case err, ok := <-cw.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("certificate watcher error: %v", err)
+		}
+	}
+}
+
+func (cw *grpcCertWatcher) Close() error {
+	close(cw.done)
+	return cw.watcher.Close()
+}
+
+func (cfg GrpcTLSConfig) caPool() (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	pem, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+	}
+	return pool, nil
+}
+
+// bearerTokenCredentials implements credentials.PerRPCCredentials, attaching
+// a static bearer token to every outbound registry RPC, for control planes
+// that authenticate agents with a shared secret or a long-lived JWT rather
+// than (or in addition to) mTLS.
+type bearerTokenCredentials struct {
+	token string
+}
+
+// NewBearerTokenCredentials returns a credentials.PerRPCCredentials that
+// attaches token as a standard "authorization: Bearer <token>" header to
+// every RPC issued through it. Pass it to WithPerRPCCredentials, or rely on
+// PARALLAX_AUTH_TOKEN to install one automatically.
+func NewBearerTokenCredentials(token string) credentials.PerRPCCredentials {
+	return &bearerTokenCredentials{token: token}
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (c *bearerTokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+// Returns false so a bearer token can still be used against a control
+// plane that isn't (yet) TLS-terminated; pair with GrpcTLSConfig/WithTLS
+// when the deployment requires it.
+func (c *bearerTokenCredentials) RequireTransportSecurity() bool { return false }
@@ -0,0 +1,88 @@
+package parallax
+
+import (
+	"context"
+	"fmt"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Well-known service names probed against the control plane's
+// grpc.health.v1.Health server, letting callers distinguish a fully down
+// control plane from a single degraded service.
+const (
+	PatternServiceName   = "parallax.PatternService"
+	AgentServiceName     = "parallax.AgentService"
+	ExecutionServiceName = "parallax.ExecutionService"
+)
+
+// ServiceStatus mirrors healthpb.HealthCheckResponse_ServingStatus, keeping
+// the generated health protocol's enum out of the SDK's public API.
+type ServiceStatus string
+
+const (
+	StatusServing    ServiceStatus = "SERVING"
+	StatusNotServing ServiceStatus = "NOT_SERVING"
+	StatusUnknown    ServiceStatus = "UNKNOWN"
+)
+
+func serviceStatusFromProto(s healthpb.HealthCheckResponse_ServingStatus) ServiceStatus {
+	switch s {
+	case healthpb.HealthCheckResponse_SERVING:
+		return StatusServing
+	case healthpb.HealthCheckResponse_NOT_SERVING:
+		return StatusNotServing
+	default:
+		return StatusUnknown
+	}
+}
+
+// HealthCheck performs a point-in-time probe of service against the
+// standard gRPC health-checking protocol (grpc.health.v1.Health.Check).
+// Pass "" to probe the control plane's overall status rather than one
+// service.
+func (c *Client) HealthCheck(ctx context.Context, service string) (ServiceStatus, error) {
+	healthClient, err := c.clientSet.HealthClient(c.endpoint)
+	if err != nil {
+		return StatusUnknown, err
+	}
+
+	resp, err := healthClient.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return StatusUnknown, fmt.Errorf("health check failed for %q: %w", service, err)
+	}
+	return serviceStatusFromProto(resp.Status), nil
+}
+
+// WatchHealth streams status transitions for service (SERVING/NOT_SERVING)
+// until ctx is cancelled or the server ends the stream, via
+// grpc.health.v1.Health.Watch. The returned channel is closed when the
+// stream ends.
+func (c *Client) WatchHealth(ctx context.Context, service string) (<-chan ServiceStatus, error) {
+	healthClient, err := c.clientSet.HealthClient(c.endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := healthClient.Watch(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return nil, fmt.Errorf("health watch failed for %q: %w", service, err)
+	}
+
+	ch := make(chan ServiceStatus)
+	go func() {
+		defer close(ch)
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- serviceStatusFromProto(resp.Status):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,104 @@
+package parallax
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	healthsrv "google.golang.org/grpc/health"
+)
+
+// newTestHealthClient starts an in-process gRPC server exposing the
+// standard health service and returns a Client wired to it over a bufconn
+// dialer, plus the health server so tests can flip serving status.
+func newTestHealthClient(t *testing.T) (*Client, *healthsrv.Server) {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	health := healthsrv.NewServer()
+	healthpb.RegisterHealthServer(srv, health)
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}
+
+	clientSet := NewRegistryClientSet(ClientSetOptions{
+		DialOptions: []grpc.DialOption{
+			grpc.WithContextDialer(dialer),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		},
+	})
+
+	client, err := NewClient(ClientConfig{
+		Endpoint:  "bufnet",
+		Logger:    zap.NewNop(),
+		ClientSet: clientSet,
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, health
+}
+
+func TestClientHealthCheck(t *testing.T) {
+	client, health := newTestHealthClient(t)
+	health.SetServingStatus(PatternServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status, err := client.HealthCheck(ctx, PatternServiceName)
+	if err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+	if status != StatusServing {
+		t.Errorf("status = %v, want %v", status, StatusServing)
+	}
+
+	health.SetServingStatus(PatternServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	status, err = client.HealthCheck(ctx, PatternServiceName)
+	if err != nil {
+		t.Fatalf("HealthCheck: %v", err)
+	}
+	if status != StatusNotServing {
+		t.Errorf("status = %v, want %v", status, StatusNotServing)
+	}
+}
+
+func TestClientWatchHealth(t *testing.T) {
+	client, health := newTestHealthClient(t)
+	health.SetServingStatus(AgentServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ch, err := client.WatchHealth(ctx, AgentServiceName)
+	if err != nil {
+		t.Fatalf("WatchHealth: %v", err)
+	}
+
+	if status := <-ch; status != StatusServing {
+		t.Fatalf("first status = %v, want %v", status, StatusServing)
+	}
+
+	health.SetServingStatus(AgentServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	if status := <-ch; status != StatusNotServing {
+		t.Fatalf("second status = %v, want %v", status, StatusNotServing)
+	}
+}
@@ -0,0 +1,268 @@
+package parallax
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"parallax/sdk-go/generated"
+)
+
+// RecoveryHandler converts a value recovered from a panic inside a unary
+// or streaming handler into the error returned to the caller. WithRecovery
+// calls it from within the deferred recover, so it may still log or
+// record metrics but must not re-panic.
+type RecoveryHandler func(ctx context.Context, p interface{}) error
+
+// AuthValidator authenticates a single incoming RPC, returning a non-nil
+// error (surfaced to the caller as-is, so implementations should use
+// codes.Unauthenticated) to reject it.
+type AuthValidator func(ctx context.Context, fullMethod string) error
+
+// MetricsSink receives the latency, error, and (for Analyze/StreamAnalyze)
+// self-reported confidence of every RPC the WithMetrics interceptor
+// observes. *Metrics implements MetricsSink, so the common case is
+// WithMetrics(parallax.NewMetrics(registry), nil); a custom sink lets
+// callers forward these observations elsewhere without depending on
+// prometheus.Registry.
+type MetricsSink interface {
+	ObserveRPC(method string, err error, duration time.Duration)
+	ObserveConfidence(agentID string, confidence float64)
+}
+
+// serverOptions accumulates the unary/stream interceptors ServerOptions
+// contribute, in the order they were applied.
+type serverOptions struct {
+	unary  []grpc.UnaryServerInterceptor
+	stream []grpc.StreamServerInterceptor
+}
+
+// ServerOption adds an interceptor to the chain ParallaxAgent.Serve
+// installs via WithServerOptions, alongside the SDK's own otelgrpc/
+// *Metrics instrumentation. Options run in the order passed to
+// WithServerOptions; put WithRecovery first so a later interceptor's
+// panic is still caught.
+type ServerOption func(*serverOptions)
+
+// chainServerOptions collects opts into the grpc.ServerOption(s)
+// ParallaxAgent.Serve appends to its own.
+func chainServerOptions(opts []ServerOption) []grpc.ServerOption {
+	var so serverOptions
+	for _, opt := range opts {
+		opt(&so)
+	}
+
+	var grpcOpts []grpc.ServerOption
+	if len(so.unary) > 0 {
+		grpcOpts = append(grpcOpts, grpc.ChainUnaryInterceptor(so.unary...))
+	}
+	if len(so.stream) > 0 {
+		grpcOpts = append(grpcOpts, grpc.ChainStreamInterceptor(so.stream...))
+	}
+	return grpcOpts
+}
+
+// DefaultRecoveryHandler builds the RecoveryHandler WithRecovery installs
+// when handler is nil: it logs the panic value and stack trace via
+// logger and returns codes.Internal, never leaking the panic value
+// itself to the caller.
+func DefaultRecoveryHandler(logger *zap.Logger) RecoveryHandler {
+	return func(ctx context.Context, p interface{}) error {
+		logger.Error("recovered from panic in gRPC handler",
+			zap.Any("panic", p),
+			zap.String("stack", string(debug.Stack())),
+		)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// WithRecovery installs a panic-recovery interceptor on both the unary
+// and streaming path, so a panic inside a handler (most commonly an
+// agent's AnalyzeFunc) surfaces to the caller as codes.Internal instead
+// of crashing the process. A nil handler defaults to
+// DefaultRecoveryHandler(logger).
+func WithRecovery(logger *zap.Logger, handler RecoveryHandler) ServerOption {
+	if handler == nil {
+		handler = DefaultRecoveryHandler(logger)
+	}
+	return func(o *serverOptions) {
+		o.unary = append(o.unary, recoveryUnaryInterceptor(handler))
+		o.stream = append(o.stream, recoveryStreamInterceptor(handler))
+	}
+}
+
+func recoveryUnaryInterceptor(handler RecoveryHandler) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = handler(ctx, p)
+			}
+		}()
+		return next(ctx, req)
+	}
+}
+
+func recoveryStreamInterceptor(handler RecoveryHandler) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = handler(ss.Context(), p)
+			}
+		}()
+		return next(srv, ss)
+	}
+}
+
+// TokenMetadataKey is the ParallaxAgent.Metadata key WithAgentTokenAuth
+// reads the agent's expected bearer token from.
+const TokenMetadataKey = "auth_token"
+
+// WithAgentTokenAuth returns an AuthValidator comparing the
+// "authorization" gRPC metadata (a "Bearer <token>" value) on every RPC
+// against agent.Metadata[TokenMetadataKey]. An agent with no token
+// configured there accepts every call, so enabling auth is opt-in per
+// agent.
+func WithAgentTokenAuth(agent *ParallaxAgent) AuthValidator {
+	return func(ctx context.Context, fullMethod string) error {
+		expected, _ := agent.Metadata[TokenMetadataKey].(string)
+		if expected == "" {
+			return nil
+		}
+
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return status.Error(codes.Unauthenticated, "missing authorization metadata")
+		}
+		if bearerToken(md) != expected {
+			return status.Error(codes.Unauthenticated, "invalid or missing token")
+		}
+		return nil
+	}
+}
+
+// WithAuth installs validator on both the unary and streaming path,
+// rejecting a call before it reaches the handler when validator returns
+// an error.
+func WithAuth(validator AuthValidator) ServerOption {
+	return func(o *serverOptions) {
+		o.unary = append(o.unary, authUnaryInterceptor(validator))
+		o.stream = append(o.stream, authStreamInterceptor(validator))
+	}
+}
+
+func authUnaryInterceptor(validator AuthValidator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		if err := validator(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func authStreamInterceptor(validator AuthValidator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		if err := validator(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return next(srv, ss)
+	}
+}
+
+// bearerToken extracts the token from an incoming "authorization: Bearer
+// <token>" metadata value, or the raw value if it isn't Bearer-prefixed.
+func bearerToken(md metadata.MD) string {
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(values[0], "Bearer ")
+}
+
+// WithMetrics installs an additional observability interceptor that
+// records every RPC's latency and error into sink, and — for
+// Analyze/StreamAnalyze responses — the responding agent's self-reported
+// AgentResult.Confidence. When calibrator is non-nil, the confidence
+// recorded is first run through calibrator.Calibrated, so a sink
+// comparing confidence across agents sees the calibrated value rather
+// than each agent's raw self-report. It runs alongside, not instead of,
+// the SDK's own *Metrics/otelgrpc instrumentation installed by
+// ParallaxAgent.Serve.
+func WithMetrics(sink MetricsSink, calibrator *ConfidenceCalibrator) ServerOption {
+	return func(o *serverOptions) {
+		o.unary = append(o.unary, metricsUnaryInterceptor(sink, calibrator))
+		o.stream = append(o.stream, metricsStreamInterceptor(sink, calibrator))
+	}
+}
+
+func metricsUnaryInterceptor(sink MetricsSink, calibrator *ConfidenceCalibrator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		start := timeNow()
+		resp, err := next(ctx, req)
+		sink.ObserveRPC(info.FullMethod, err, timeNow().Sub(start))
+		recordConfidence(sink, calibrator, resp)
+		return resp, err
+	}
+}
+
+func metricsStreamInterceptor(sink MetricsSink, calibrator *ConfidenceCalibrator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		start := timeNow()
+		err := next(srv, &confidenceRecordingStream{ServerStream: ss, sink: sink, calibrator: calibrator})
+		sink.ObserveRPC(info.FullMethod, err, timeNow().Sub(start))
+		return err
+	}
+}
+
+// confidenceRecordingStream wraps a grpc.ServerStream to observe every
+// *generated.ConfidenceResult StreamAnalyze sends, the streaming
+// counterpart to metricsUnaryInterceptor's single-response check.
+type confidenceRecordingStream struct {
+	grpc.ServerStream
+	sink       MetricsSink
+	calibrator *ConfidenceCalibrator
+}
+
+func (s *confidenceRecordingStream) SendMsg(m interface{}) error {
+	recordConfidence(s.sink, s.calibrator, m)
+	return s.ServerStream.SendMsg(m)
+}
+
+// recordConfidence reports resp's confidence to sink when resp is a
+// *generated.ConfidenceResult, calibrating it first if calibrator is set.
+func recordConfidence(sink MetricsSink, calibrator *ConfidenceCalibrator, resp interface{}) {
+	result, ok := resp.(*generated.ConfidenceResult)
+	if !ok {
+		return
+	}
+
+	confidence := result.Confidence
+	if calibrator != nil {
+		confidence = calibrator.Calibrated(result.AgentId, confidence)
+	}
+	sink.ObserveConfidence(result.AgentId, confidence)
+}
+
+// WithClientTokenAuth returns client interceptors that attach token as a
+// "Bearer" authorization metadata value on every outgoing RPC, the client
+// side of WithAgentTokenAuth. NewClient wires these in automatically via
+// ClientSetOptions when ClientConfig.AuthToken is set.
+func WithClientTokenAuth(token string) (grpc.UnaryClientInterceptor, grpc.StreamClientInterceptor) {
+	attach := func(ctx context.Context) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+
+	unary := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(attach(ctx), method, req, reply, cc, opts...)
+	}
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(attach(ctx), desc, cc, method, opts...)
+	}
+	return unary, stream
+}
@@ -0,0 +1,34 @@
+package kv
+
+import "encoding/json"
+
+// JSONCodec is a Codec that encodes values as JSON, decoding into a fresh
+// value produced by New each call. It's the Codec used for AgentInfo and
+// lease records, whose structs already carry json tags for the SDK's REST
+// surface.
+type JSONCodec struct {
+	// ID is returned by CodecID. It lets backends that gossip or store
+	// payloads from multiple codecs in the same keyspace (e.g. memberlist)
+	// tell them apart.
+	ID string
+
+	// New returns a fresh zero value for Decode to unmarshal into, e.g.
+	// func() interface{} { return &parallax.AgentInfo{} }.
+	New func() interface{}
+}
+
+func (c JSONCodec) CodecID() string {
+	return c.ID
+}
+
+func (c JSONCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c JSONCodec) Decode(b []byte) (interface{}, error) {
+	v := c.New()
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
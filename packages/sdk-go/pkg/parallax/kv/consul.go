@@ -0,0 +1,168 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// ConsulConfig configures the Consul backend. CAS uses Consul's native
+// check-and-set index rather than the version counter the inmemory and
+// memberlist backends use.
+type ConsulConfig struct {
+	// Address is the Consul HTTP API address, e.g. "127.0.0.1:8500".
+	Address string `json:"address,omitempty"`
+
+	// Token is the ACL token used for requests, if Consul ACLs are enabled.
+	Token string `json:"token,omitempty"`
+}
+
+type consulClient struct {
+	kv     *consulapi.KV
+	prefix string
+	codec  Codec
+}
+
+func newConsulClient(cfg ConsulConfig, prefix string, codec Codec) (Client, error) {
+	apiConfig := consulapi.DefaultConfig()
+	if cfg.Address != "" {
+		apiConfig.Address = cfg.Address
+	}
+	if cfg.Token != "" {
+		apiConfig.Token = cfg.Token
+	}
+
+	client, err := consulapi.NewClient(apiConfig)
+	if err != nil {
+		return nil, fmt.Errorf("kv: create consul client: %w", err)
+	}
+
+	return &consulClient{kv: client.KV(), prefix: prefix, codec: codec}, nil
+}
+
+func (c *consulClient) Get(ctx context.Context, key string) (interface{}, error) {
+	pair, _, err := c.kv.Get(c.prefix+key, (&consulapi.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, nil
+	}
+	return c.codec.Decode(pair.Value)
+}
+
+func (c *consulClient) CAS(ctx context.Context, key string, f func(in interface{}) (out interface{}, retry bool, err error)) error {
+	fullKey := c.prefix + key
+
+	for {
+		pair, _, err := c.kv.Get(fullKey, (&consulapi.QueryOptions{}).WithContext(ctx))
+		if err != nil {
+			return err
+		}
+
+		var cur interface{}
+		var modifyIndex uint64
+		if pair != nil {
+			cur, err = c.codec.Decode(pair.Value)
+			if err != nil {
+				return err
+			}
+			modifyIndex = pair.ModifyIndex
+		}
+
+		out, retry, err := f(cur)
+		if err != nil {
+			return err
+		}
+		if out == nil {
+			return nil
+		}
+
+		encoded, err := c.codec.Encode(out)
+		if err != nil {
+			return err
+		}
+
+		ok, _, err := c.kv.CAS(&consulapi.KVPair{Key: fullKey, Value: encoded, ModifyIndex: modifyIndex}, (&consulapi.WriteOptions{}).WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if !retry {
+			return nil
+		}
+	}
+}
+
+func (c *consulClient) Delete(ctx context.Context, key string) error {
+	_, err := c.kv.Delete(c.prefix+key, (&consulapi.WriteOptions{}).WithContext(ctx))
+	return err
+}
+
+func (c *consulClient) WatchKey(ctx context.Context, key string, f func(interface{}) bool) {
+	fullKey := c.prefix + key
+	var lastIndex uint64
+
+	for {
+		pair, meta, err := c.kv.Get(fullKey, (&consulapi.QueryOptions{WaitIndex: lastIndex}).WithContext(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		lastIndex = meta.LastIndex
+
+		var value interface{}
+		if pair != nil {
+			value, err = c.codec.Decode(pair.Value)
+			if err != nil {
+				continue
+			}
+		}
+		if !f(value) {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (c *consulClient) WatchPrefix(ctx context.Context, prefix string, f func(key string, val interface{}) bool) {
+	fullPrefix := c.prefix + prefix
+	var lastIndex uint64
+
+	for {
+		pairs, meta, err := c.kv.List(fullPrefix, (&consulapi.QueryOptions{WaitIndex: lastIndex}).WithContext(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		lastIndex = meta.LastIndex
+
+		for _, pair := range pairs {
+			value, err := c.codec.Decode(pair.Value)
+			if err != nil {
+				continue
+			}
+			key := strings.TrimPrefix(pair.Key, c.prefix)
+			if !f(key, value) {
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (c *consulClient) Close() error {
+	return nil
+}
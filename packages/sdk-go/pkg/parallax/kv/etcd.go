@@ -0,0 +1,179 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdConfig configures the etcd backend.
+type EtcdConfig struct {
+	// Endpoints lists the etcd cluster members, e.g. []string{"127.0.0.1:2379"}.
+	Endpoints []string `json:"endpoints,omitempty"`
+
+	// DialTimeout bounds the initial connection attempt. Defaults to 5s.
+	DialTimeout time.Duration `json:"dialTimeout,omitempty"`
+
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+type etcdClient struct {
+	cli    *clientv3.Client
+	prefix string
+	codec  Codec
+}
+
+func newEtcdClient(cfg EtcdConfig, prefix string, codec Codec) (Client, error) {
+	dialTimeout := cfg.DialTimeout
+	if dialTimeout == 0 {
+		dialTimeout = 5 * time.Second
+	}
+
+	cli, err := clientv3.New(clientv3.Config{
+		Endpoints:   cfg.Endpoints,
+		DialTimeout: dialTimeout,
+		Username:    cfg.Username,
+		Password:    cfg.Password,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kv: create etcd client: %w", err)
+	}
+
+	return &etcdClient{cli: cli, prefix: prefix, codec: codec}, nil
+}
+
+func (c *etcdClient) Get(ctx context.Context, key string) (interface{}, error) {
+	resp, err := c.cli.Get(ctx, c.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+	return c.codec.Decode(resp.Kvs[0].Value)
+}
+
+func (c *etcdClient) CAS(ctx context.Context, key string, f func(in interface{}) (out interface{}, retry bool, err error)) error {
+	fullKey := c.prefix + key
+
+	for {
+		resp, err := c.cli.Get(ctx, fullKey)
+		if err != nil {
+			return err
+		}
+
+		var cur interface{}
+		var modRevision int64
+		if len(resp.Kvs) > 0 {
+			cur, err = c.codec.Decode(resp.Kvs[0].Value)
+			if err != nil {
+				return err
+			}
+			modRevision = resp.Kvs[0].ModRevision
+		}
+
+		out, retry, err := f(cur)
+		if err != nil {
+			return err
+		}
+		if out == nil {
+			return nil
+		}
+
+		encoded, err := c.codec.Encode(out)
+		if err != nil {
+			return err
+		}
+
+		txnResp, err := c.cli.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(fullKey), "=", modRevision)).
+			Then(clientv3.OpPut(fullKey, string(encoded))).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if txnResp.Succeeded {
+			return nil
+		}
+		if !retry {
+			return nil
+		}
+	}
+}
+
+func (c *etcdClient) Delete(ctx context.Context, key string) error {
+	_, err := c.cli.Delete(ctx, c.prefix+key)
+	return err
+}
+
+func (c *etcdClient) WatchKey(ctx context.Context, key string, f func(interface{}) bool) {
+	fullKey := c.prefix + key
+
+	var value interface{}
+	if resp, err := c.cli.Get(ctx, fullKey); err == nil && len(resp.Kvs) > 0 {
+		value, _ = c.codec.Decode(resp.Kvs[0].Value)
+	}
+	if !f(value) {
+		return
+	}
+
+	watchCh := c.cli.Watch(ctx, fullKey)
+	for resp := range watchCh {
+		for _, event := range resp.Events {
+			var value interface{}
+			if event.Type != clientv3.EventTypeDelete {
+				var err error
+				value, err = c.codec.Decode(event.Kv.Value)
+				if err != nil {
+					continue
+				}
+			}
+			if !f(value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *etcdClient) WatchPrefix(ctx context.Context, prefix string, f func(key string, val interface{}) bool) {
+	fullPrefix := c.prefix + prefix
+
+	resp, err := c.cli.Get(ctx, fullPrefix, clientv3.WithPrefix())
+	if err == nil {
+		for _, kv := range resp.Kvs {
+			value, err := c.codec.Decode(kv.Value)
+			if err != nil {
+				continue
+			}
+			if !f(strings.TrimPrefix(string(kv.Key), c.prefix), value) {
+				return
+			}
+		}
+	}
+
+	watchCh := c.cli.Watch(ctx, fullPrefix, clientv3.WithPrefix())
+	for resp := range watchCh {
+		for _, event := range resp.Events {
+			key := strings.TrimPrefix(string(event.Kv.Key), c.prefix)
+			var value interface{}
+			if event.Type != clientv3.EventTypeDelete {
+				var err error
+				value, err = c.codec.Decode(event.Kv.Value)
+				if err != nil {
+					continue
+				}
+			}
+			if !f(key, value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *etcdClient) Close() error {
+	return c.cli.Close()
+}
@@ -0,0 +1,191 @@
+package kv
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// inmemoryClient is a process-local Client backend with real optimistic
+// concurrency (CAS) and watch semantics, unlike the other backends it has
+// no external dependency, making it the default and the one tests and
+// single-process setups use.
+type inmemoryClient struct {
+	codec Codec
+
+	mu      sync.RWMutex
+	entries map[string]inmemoryEntry
+
+	watchMu        sync.Mutex
+	keyWatchers    map[string][]chan struct{}
+	prefixWatchers map[string][]chan struct{}
+}
+
+type inmemoryEntry struct {
+	value   interface{}
+	version uint64
+}
+
+func newInmemoryClient(codec Codec) *inmemoryClient {
+	return &inmemoryClient{
+		codec:          codec,
+		entries:        make(map[string]inmemoryEntry),
+		keyWatchers:    make(map[string][]chan struct{}),
+		prefixWatchers: make(map[string][]chan struct{}),
+	}
+}
+
+func (c *inmemoryClient) Get(ctx context.Context, key string) (interface{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.entries[key].value, nil
+}
+
+func (c *inmemoryClient) CAS(ctx context.Context, key string, f func(in interface{}) (out interface{}, retry bool, err error)) error {
+	for {
+		c.mu.RLock()
+		cur := c.entries[key]
+		c.mu.RUnlock()
+
+		out, retry, err := f(cur.value)
+		if err != nil {
+			return err
+		}
+		if out == nil {
+			return nil
+		}
+
+		c.mu.Lock()
+		if c.entries[key].version != cur.version {
+			c.mu.Unlock()
+			if retry {
+				continue
+			}
+			return nil
+		}
+		c.entries[key] = inmemoryEntry{value: out, version: cur.version + 1}
+		c.mu.Unlock()
+
+		c.notify(key)
+		return nil
+	}
+}
+
+func (c *inmemoryClient) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+	c.notify(key)
+	return nil
+}
+
+func (c *inmemoryClient) WatchKey(ctx context.Context, key string, f func(interface{}) bool) {
+	notifyCh := make(chan struct{}, 1)
+	c.watchMu.Lock()
+	c.keyWatchers[key] = append(c.keyWatchers[key], notifyCh)
+	c.watchMu.Unlock()
+	defer c.unsubscribe(c.keyWatchers, key, notifyCh)
+
+	value, _ := c.Get(ctx, key)
+	if !f(value) {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-notifyCh:
+			value, _ := c.Get(ctx, key)
+			if !f(value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *inmemoryClient) WatchPrefix(ctx context.Context, prefix string, f func(key string, val interface{}) bool) {
+	notifyCh := make(chan struct{}, 1)
+	c.watchMu.Lock()
+	c.prefixWatchers[prefix] = append(c.prefixWatchers[prefix], notifyCh)
+	c.watchMu.Unlock()
+	defer c.unsubscribe(c.prefixWatchers, prefix, notifyCh)
+
+	if !c.emitPrefix(prefix, f) {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-notifyCh:
+			if !c.emitPrefix(prefix, f) {
+				return
+			}
+		}
+	}
+}
+
+func (c *inmemoryClient) emitPrefix(prefix string, f func(key string, val interface{}) bool) bool {
+	c.mu.RLock()
+	matches := make(map[string]interface{})
+	for key, entry := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			matches[key] = entry.value
+		}
+	}
+	c.mu.RUnlock()
+
+	for key, value := range matches {
+		if !f(key, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *inmemoryClient) Close() error {
+	return nil
+}
+
+// notify wakes every key watcher on key and every prefix watcher whose
+// prefix matches key, coalescing bursts via each channel's buffer of one.
+func (c *inmemoryClient) notify(key string) {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+
+	for _, ch := range c.keyWatchers[key] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	for prefix, chans := range c.prefixWatchers {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		for _, ch := range chans {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+func (c *inmemoryClient) unsubscribe(watchers map[string][]chan struct{}, key string, notifyCh chan struct{}) {
+	c.watchMu.Lock()
+	defer c.watchMu.Unlock()
+
+	chans := watchers[key]
+	for i, ch := range chans {
+		if ch == notifyCh {
+			watchers[key] = append(chans[:i], chans[i+1:]...)
+			break
+		}
+	}
+	if len(watchers[key]) == 0 {
+		delete(watchers, key)
+	}
+}
@@ -0,0 +1,216 @@
+package kv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/memberlist"
+)
+
+// MemberlistConfig configures the gossip-based memberlist backend. It
+// trades strong consistency for no external dependency: every node keeps
+// its own copy of the keyspace. CAS checks a version number only against
+// the local copy, same as the inmemory backend's CAS loop; an update
+// gossiped from a peer overwrites the local value as it arrives, so
+// concurrent writes to one key on different nodes end with whichever
+// update each node received last.
+type MemberlistConfig struct {
+	// NodeName must be unique within the cluster. Defaults to the host's
+	// hostname if empty.
+	NodeName string `json:"nodeName,omitempty"`
+
+	// BindAddr/BindPort are where this node listens for gossip traffic.
+	BindAddr string `json:"bindAddr,omitempty"`
+	BindPort int    `json:"bindPort,omitempty"`
+
+	// JoinMembers seeds the gossip ring; at least one reachable member is
+	// needed to join an existing cluster, empty starts a new one.
+	JoinMembers []string `json:"joinMembers,omitempty"`
+
+	// GossipInterval controls how often this node gossips its state to
+	// random peers. Defaults to memberlist's own default (200ms) when zero.
+	GossipInterval time.Duration `json:"gossipInterval,omitempty"`
+}
+
+// memberlistClient stores the keyspace as local state (like inmemoryClient)
+// and broadcasts mutations to the rest of the cluster via memberlist's
+// gossip broadcast queue. A remote update replaces the local value
+// outright: gossip entries carry no version, so nothing is compared.
+type memberlistClient struct {
+	*inmemoryClient
+
+	prefix    string
+	codec     Codec
+	ml        *memberlist.Memberlist
+	broadcast *memberlist.TransmitLimitedQueue
+}
+
+func newMemberlistClient(cfg MemberlistConfig, prefix string, codec Codec) (Client, error) {
+	c := &memberlistClient{
+		inmemoryClient: newInmemoryClient(codec),
+		prefix:         prefix,
+		codec:          codec,
+	}
+
+	mlConfig := memberlist.DefaultLANConfig()
+	if cfg.NodeName != "" {
+		mlConfig.Name = cfg.NodeName
+	}
+	if cfg.BindAddr != "" {
+		mlConfig.BindAddr = cfg.BindAddr
+	}
+	if cfg.BindPort != 0 {
+		mlConfig.BindPort = cfg.BindPort
+		mlConfig.AdvertisePort = cfg.BindPort
+	}
+	if cfg.GossipInterval != 0 {
+		mlConfig.GossipInterval = cfg.GossipInterval
+	}
+	mlConfig.Delegate = &memberlistDelegate{client: c}
+
+	ml, err := memberlist.Create(mlConfig)
+	if err != nil {
+		return nil, fmt.Errorf("kv: create memberlist: %w", err)
+	}
+	c.ml = ml
+	c.broadcast = &memberlist.TransmitLimitedQueue{
+		NumNodes:       func() int { return ml.NumMembers() },
+		RetransmitMult: 3,
+	}
+
+	if len(cfg.JoinMembers) > 0 {
+		if _, err := ml.Join(cfg.JoinMembers); err != nil {
+			return nil, fmt.Errorf("kv: join memberlist cluster: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// Get applies c.prefix before reading the local inmemoryClient state, the
+// same multi-tenancy guarantee consul.go and etcd.go honor.
+func (c *memberlistClient) Get(ctx context.Context, key string) (interface{}, error) {
+	return c.inmemoryClient.Get(ctx, c.prefix+key)
+}
+
+func (c *memberlistClient) CAS(ctx context.Context, key string, f func(in interface{}) (out interface{}, retry bool, err error)) error {
+	fullKey := c.prefix + key
+	if err := c.inmemoryClient.CAS(ctx, fullKey, f); err != nil {
+		return err
+	}
+	c.broadcastKey(fullKey)
+	return nil
+}
+
+func (c *memberlistClient) Delete(ctx context.Context, key string) error {
+	fullKey := c.prefix + key
+	if err := c.inmemoryClient.Delete(ctx, fullKey); err != nil {
+		return err
+	}
+	c.broadcastKey(fullKey)
+	return nil
+}
+
+// WatchKey applies c.prefix before delegating to the local inmemoryClient,
+// the same multi-tenancy guarantee consul.go and etcd.go honor.
+func (c *memberlistClient) WatchKey(ctx context.Context, key string, f func(interface{}) bool) {
+	c.inmemoryClient.WatchKey(ctx, c.prefix+key, f)
+}
+
+// WatchPrefix applies c.prefix before delegating to the local
+// inmemoryClient, stripping it back off the keys f observes, the same
+// multi-tenancy guarantee consul.go and etcd.go honor.
+func (c *memberlistClient) WatchPrefix(ctx context.Context, prefix string, f func(key string, val interface{}) bool) {
+	fullPrefix := c.prefix + prefix
+	c.inmemoryClient.WatchPrefix(ctx, fullPrefix, func(key string, val interface{}) bool {
+		return f(strings.TrimPrefix(key, c.prefix), val)
+	})
+}
+
+func (c *memberlistClient) Close() error {
+	if err := c.ml.Leave(10 * time.Second); err != nil {
+		return err
+	}
+	return c.ml.Shutdown()
+}
+
+// broadcastKey gossips fullKey's current local value to the rest of the
+// cluster. Callers pass the already c.prefix-qualified key, matching what
+// CAS/Delete just wrote into c.inmemoryClient and what NotifyMsg merges
+// incoming updates into, so prefix handling stays consistent regardless of
+// whether a key's value came from this node or a peer's.
+func (c *memberlistClient) broadcastKey(fullKey string) {
+	value, _ := c.inmemoryClient.Get(context.Background(), fullKey)
+	encoded, err := c.codec.Encode(value)
+	if err != nil {
+		return
+	}
+	c.broadcast.QueueBroadcast(gossipEntry{key: fullKey, value: encoded})
+}
+
+// memberlistDelegate feeds remote gossip state into the local inmemoryClient
+// and supplies this node's own state to peers that ask for it.
+type memberlistDelegate struct {
+	client *memberlistClient
+}
+
+func (d *memberlistDelegate) NodeMeta(limit int) []byte { return nil }
+
+func (d *memberlistDelegate) NotifyMsg(msg []byte) {
+	var entry gossipEntry
+	if err := entry.unmarshal(msg); err != nil {
+		return
+	}
+	value, err := d.client.codec.Decode(entry.value)
+	if err != nil {
+		return
+	}
+	d.client.mu.Lock()
+	cur := d.client.entries[entry.key]
+	d.client.entries[entry.key] = inmemoryEntry{value: value, version: cur.version + 1}
+	d.client.mu.Unlock()
+	d.client.notify(entry.key)
+}
+
+func (d *memberlistDelegate) GetBroadcasts(overhead, limit int) [][]byte {
+	return d.client.broadcast.GetBroadcasts(overhead, limit)
+}
+
+func (d *memberlistDelegate) LocalState(join bool) []byte { return nil }
+
+func (d *memberlistDelegate) MergeRemoteState(buf []byte, join bool) {}
+
+// gossipEntry is the wire format broadcast over the gossip transport.
+type gossipEntry struct {
+	key   string
+	value []byte
+}
+
+func (e gossipEntry) Invalidates(other memberlist.Broadcast) bool {
+	o, ok := other.(gossipEntry)
+	return ok && o.key == e.key
+}
+
+func (e gossipEntry) Message() []byte {
+	b, _ := e.marshal()
+	return b
+}
+
+func (e gossipEntry) Finished() {}
+
+func (e gossipEntry) marshal() ([]byte, error) {
+	return append([]byte(e.key+"\x00"), e.value...), nil
+}
+
+func (e *gossipEntry) unmarshal(b []byte) error {
+	for i, ch := range b {
+		if ch == 0 {
+			e.key = string(b[:i])
+			e.value = b[i+1:]
+			return nil
+		}
+	}
+	return fmt.Errorf("kv: malformed gossip entry")
+}
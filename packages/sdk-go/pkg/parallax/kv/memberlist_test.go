@@ -0,0 +1,104 @@
+package kv
+
+import (
+	"context"
+	"testing"
+)
+
+// newTestMemberlistClient builds a memberlistClient around a bare
+// inmemoryClient, skipping newMemberlistClient's real memberlist.Create
+// (which binds a UDP port) since these tests only exercise prefix
+// handling on the local entries map, not gossip.
+func newTestMemberlistClient(prefix string) *memberlistClient {
+	return &memberlistClient{
+		inmemoryClient: newInmemoryClient(JSONCodec{}),
+		prefix:         prefix,
+	}
+}
+
+func TestMemberlistClientGetAppliesPrefix(t *testing.T) {
+	ctx := context.Background()
+	c := newTestMemberlistClient("tenant-a/")
+
+	if err := c.inmemoryClient.CAS(ctx, "tenant-a/key", func(interface{}) (interface{}, bool, error) {
+		return "value", false, nil
+	}); err != nil {
+		t.Fatalf("seed CAS: %v", err)
+	}
+
+	value, err := c.Get(ctx, "key")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if value != "value" {
+		t.Fatalf("Get(%q) = %v, want %q", "key", value, "value")
+	}
+
+	if value, _ := c.Get(ctx, "tenant-a/key"); value != nil {
+		t.Fatalf("Get with an already-prefixed key should not find the double-prefixed entry, got %v", value)
+	}
+}
+
+func TestMemberlistClientWatchKeyAppliesPrefix(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newTestMemberlistClient("tenant-a/")
+
+	seen := make(chan interface{}, 2)
+	subscribed := make(chan struct{})
+	go c.WatchKey(ctx, "key", func(v interface{}) bool {
+		seen <- v
+		select {
+		case <-subscribed:
+		default:
+			close(subscribed)
+		}
+		return len(seen) < cap(seen)
+	})
+
+	// WatchKey registers its subscription before invoking f with the
+	// initial value, so waiting for that first callback guarantees the
+	// CAS below is observed rather than raced with subscription setup.
+	<-subscribed
+
+	if err := c.inmemoryClient.CAS(ctx, "tenant-a/key", func(interface{}) (interface{}, bool, error) {
+		return "rotated", false, nil
+	}); err != nil {
+		t.Fatalf("CAS: %v", err)
+	}
+
+	if v := <-seen; v != nil {
+		t.Fatalf("initial WatchKey value = %v, want nil", v)
+	}
+	if v := <-seen; v != "rotated" {
+		t.Fatalf("WatchKey delivered %v, want %q", v, "rotated")
+	}
+}
+
+func TestMemberlistClientWatchPrefixStripsPrefix(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newTestMemberlistClient("tenant-a/")
+
+	if err := c.inmemoryClient.CAS(ctx, "tenant-a/key", func(interface{}) (interface{}, bool, error) {
+		return "value", false, nil
+	}); err != nil {
+		t.Fatalf("seed CAS: %v", err)
+	}
+	// An entry under a different tenant's prefix must never be observed.
+	if err := c.inmemoryClient.CAS(ctx, "tenant-b/other", func(interface{}) (interface{}, bool, error) {
+		return "other", false, nil
+	}); err != nil {
+		t.Fatalf("seed CAS: %v", err)
+	}
+
+	keys := make(chan string, 1)
+	go c.WatchPrefix(ctx, "", func(key string, _ interface{}) bool {
+		keys <- key
+		return false
+	})
+
+	if key := <-keys; key != "key" {
+		t.Fatalf("WatchPrefix observed key %q, want %q (prefix stripped)", key, "key")
+	}
+}
@@ -0,0 +1,217 @@
+package parallax
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics wraps the Prometheus collectors the SDK instruments gRPC calls,
+// lease renewal, and pattern execution with. Following Coder's agent, a
+// *prometheus.Registry is threaded through ParallaxAgent.Metrics and
+// ClientConfig.Metrics rather than relying on the global default registry;
+// leaving the field nil disables instrumentation entirely, and every
+// recording method on Metrics is a nil-safe no-op so call sites don't need
+// to guard every call with "if metrics != nil".
+type Metrics struct {
+	registry *prometheus.Registry
+
+	grpcRequestsTotal   *prometheus.CounterVec
+	grpcRequestDuration *prometheus.HistogramVec
+	grpcInFlight        *prometheus.GaugeVec
+
+	leaseRenewalsTotal *prometheus.CounterVec
+	leaseTTLSeconds    *prometheus.GaugeVec
+
+	patternExecutionsTotal *prometheus.CounterVec
+
+	agentConfidence *prometheus.GaugeVec
+}
+
+// NewMetrics builds the SDK's collectors and registers them on registry,
+// reusing whatever is already registered there (safe to call once per
+// registry per process, e.g. when a single registry backs both a
+// ParallaxAgent and a Client). Pass the result to ParallaxAgent.Metrics
+// and/or ClientConfig.Metrics.
+func NewMetrics(registry *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		registry: registry,
+		grpcRequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "parallax",
+			Subsystem: "grpc",
+			Name:      "requests_total",
+			Help:      "Total gRPC requests handled, by RPC method and status code.",
+		}, []string{"method", "code"}),
+		grpcRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "parallax",
+			Subsystem: "grpc",
+			Name:      "request_duration_seconds",
+			Help:      "gRPC request latency in seconds, by RPC method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		grpcInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "parallax",
+			Subsystem: "grpc",
+			Name:      "in_flight_requests",
+			Help:      "gRPC requests currently being handled, by RPC method.",
+		}, []string{"method"}),
+		leaseRenewalsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "parallax",
+			Subsystem: "agent",
+			Name:      "lease_renewals_total",
+			Help:      "Lease keepalive outcomes, by result (renewed, lost, reregistered).",
+		}, []string{"result"}),
+		leaseTTLSeconds: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "parallax",
+			Subsystem: "agent",
+			Name:      "lease_ttl_seconds",
+			Help:      "Current lease TTL in seconds, by agent ID.",
+		}, []string{"agent_id"}),
+		patternExecutionsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "parallax",
+			Subsystem: "pattern",
+			Name:      "executions_total",
+			Help:      "Pattern executions, by pattern name and terminal status.",
+		}, []string{"pattern", "status"}),
+		agentConfidence: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "parallax",
+			Subsystem: "agent",
+			Name:      "confidence",
+			Help:      "Most recent Analyze/StreamAnalyze confidence, by agent ID.",
+		}, []string{"agent_id"}),
+	}
+
+	registerOrReuse(registry, &m.grpcRequestsTotal)
+	registerOrReuse(registry, &m.grpcRequestDuration)
+	registerOrReuse(registry, &m.grpcInFlight)
+	registerOrReuse(registry, &m.leaseRenewalsTotal)
+	registerOrReuse(registry, &m.leaseTTLSeconds)
+	registerOrReuse(registry, &m.patternExecutionsTotal)
+	registerOrReuse(registry, &m.agentConfidence)
+
+	return m
+}
+
+// registerOrReuse registers *collector on registry, swapping it for the
+// already-registered instance if registry already has a collector with the
+// same fully-qualified name (e.g. a second agent sharing one registry).
+func registerOrReuse[C prometheus.Collector](registry *prometheus.Registry, collector *C) {
+	if err := registry.Register(*collector); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			*collector = are.ExistingCollector.(C)
+		}
+	}
+}
+
+// Handler returns an http.Handler serving registry in the Prometheus text
+// exposition format. Serve starts this on ParallaxAgent.MetricsAddr when
+// Metrics is set; callers running their own HTTP server instead can mount
+// it directly:
+//
+//	metrics := parallax.NewMetrics(prometheus.NewRegistry())
+//	mux.Handle("/metrics", metrics.Handler())
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) observeGRPCRequest(method string, err error, durationSeconds float64) {
+	if m == nil {
+		return
+	}
+	m.grpcRequestsTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+	m.grpcRequestDuration.WithLabelValues(method).Observe(durationSeconds)
+}
+
+func (m *Metrics) recordLeaseRenewal(result LeaseEventType) {
+	if m == nil {
+		return
+	}
+	m.leaseRenewalsTotal.WithLabelValues(string(result)).Inc()
+}
+
+func (m *Metrics) setLeaseTTL(agentID string, ttlSeconds float64) {
+	if m == nil {
+		return
+	}
+	m.leaseTTLSeconds.WithLabelValues(agentID).Set(ttlSeconds)
+}
+
+func (m *Metrics) recordPatternExecution(pattern string, status ExecutionStatus) {
+	if m == nil {
+		return
+	}
+	m.patternExecutionsTotal.WithLabelValues(pattern, string(status)).Inc()
+}
+
+func (m *Metrics) setAgentConfidence(agentID string, confidence float64) {
+	if m == nil {
+		return
+	}
+	m.agentConfidence.WithLabelValues(agentID).Set(confidence)
+}
+
+// ObserveRPC implements MetricsSink, recording method's latency/error the
+// same way the SDK's own unaryServerInterceptor does, so WithMetrics can
+// be pointed at an agent's own *Metrics in addition to the instrumentation
+// ParallaxAgent.Serve already installs.
+func (m *Metrics) ObserveRPC(method string, err error, duration time.Duration) {
+	m.observeGRPCRequest(method, err, duration.Seconds())
+}
+
+// ObserveConfidence implements MetricsSink, recording agentID's most
+// recent confidence the same way Analyze/StreamAnalyze already do.
+func (m *Metrics) ObserveConfidence(agentID string, confidence float64) {
+	m.setAgentConfidence(agentID, confidence)
+}
+
+// unaryServerInterceptor times every unary RPC (Analyze, HealthCheck,
+// GetCapabilities) and records it under observeGRPCRequest.
+func (m *Metrics) unaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		m.trackInFlight(info.FullMethod, 1)
+		defer m.trackInFlight(info.FullMethod, -1)
+
+		start := timeNow()
+		resp, err := handler(ctx, req)
+		m.observeGRPCRequest(info.FullMethod, err, timeNow().Sub(start).Seconds())
+		return resp, err
+	}
+}
+
+func (m *Metrics) trackInFlight(method string, delta float64) {
+	if m == nil {
+		return
+	}
+	m.grpcInFlight.WithLabelValues(method).Add(delta)
+}
+
+// streamServerInterceptor times every streaming RPC (StreamAnalyze) and
+// records it under observeGRPCRequest.
+func (m *Metrics) streamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		m.trackInFlight(info.FullMethod, 1)
+		defer m.trackInFlight(info.FullMethod, -1)
+
+		start := timeNow()
+		err := handler(srv, ss)
+		m.observeGRPCRequest(info.FullMethod, err, timeNow().Sub(start).Seconds())
+		return err
+	}
+}
+
+// unaryClientInterceptor times every outbound unary RPC the Client issues
+// (Register, Renew, ListAgents, ...) and records it under
+// observeGRPCRequest.
+func (m *Metrics) unaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := timeNow()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		m.observeGRPCRequest(method, err, timeNow().Sub(start).Seconds())
+		return err
+	}
+}
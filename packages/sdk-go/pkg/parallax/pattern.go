@@ -4,197 +4,1006 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"parallax/sdk-go/generated"
 )
 
-// patternService implements PatternService
+// patternService implements PatternService on top of a registry of
+// PatternExecutors. consensus-builder and map-reduce are registered as
+// built-ins at construction time; third parties extend it via Register.
 type patternService struct {
 	client *Client
 	logger *zap.Logger
+
+	mu        sync.RWMutex
+	executors map[string]PatternExecutor
+	patterns  map[string]*Pattern
+
+	executionsMu sync.Mutex
+	executions   map[string]*PatternExecution
+	subscribers  []chan *PatternExecution
+
+	// stats backs the "power-of-two-choices" and "expertise-weighted"
+	// built-in Selectors with rolling per-agent latency/confidence,
+	// fed by watching this service's own StreamExecutions (see
+	// newPatternService).
+	stats *SelectorStats
+
+	jobsMu  sync.Mutex
+	jobs    map[string]*JobExecution
+	jobDone map[string]chan struct{}
+	jobSubs map[string][]chan *JobEvent
 }
 
-// List returns all available patterns
-func (s *patternService) List(ctx context.Context) ([]*Pattern, error) {
-	// TODO: Implement gRPC call
-	s.logger.Debug("Listing patterns")
-	
-	// Mock implementation
-	return []*Pattern{
-		{
-			Name:        "consensus-builder",
-			Description: "Builds consensus among multiple agents",
-			Enabled:     true,
-			RequiredCapabilities: []string{"analysis"},
-			Config: PatternConfig{
-				MinAgents:          3,
-				ConsensusThreshold: 0.7,
-			},
+// newPatternService builds a patternService with the built-in
+// consensus-builder and map-reduce executors registered.
+func newPatternService(client *Client, logger *zap.Logger) *patternService {
+	s := &patternService{
+		client:     client,
+		logger:     logger,
+		executors:  make(map[string]PatternExecutor),
+		patterns:   make(map[string]*Pattern),
+		executions: make(map[string]*PatternExecution),
+		jobs:       make(map[string]*JobExecution),
+		jobDone:    make(map[string]chan struct{}),
+		jobSubs:    make(map[string][]chan *JobEvent),
+		stats:      newSelectorStats(),
+	}
+
+	s.registerBuiltin(&Pattern{
+		Name:                 "consensus-builder",
+		Description:          "Builds consensus among multiple agents via weighted vote",
+		Enabled:              true,
+		RequiredCapabilities: []string{"analysis"},
+		Config: PatternConfig{
+			MinAgents:          3,
+			ConsensusThreshold: 0.7,
 		},
-		{
-			Name:        "map-reduce",
-			Description: "Distributes work across agents and aggregates results",
-			Enabled:     true,
-			RequiredCapabilities: []string{"processing"},
-			Config: PatternConfig{
-				MinAgents: 2,
-			},
+	}, &consensusBuilderExecutor{clientSet: client.clientSet, minAgents: 3, threshold: 0.7, calibrator: NewConfidenceCalibrator()})
+
+	s.registerBuiltin(&Pattern{
+		Name:                 "map-reduce",
+		Description:          "Shards work across agents and reduces their results",
+		Enabled:              true,
+		RequiredCapabilities: []string{"processing"},
+		Config: PatternConfig{
+			MinAgents: 2,
 		},
-	}, nil
+	}, &mapReduceExecutor{clientSet: client.clientSet, minAgents: 2})
+
+	go func() {
+		if err := s.stats.watch(context.Background(), s); err != nil {
+			s.logger.Warn("selector stats stream ended", zap.Error(err))
+		}
+	}()
+
+	return s
+}
+
+func (s *patternService) registerBuiltin(meta *Pattern, executor PatternExecutor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.patterns[meta.Name] = meta
+	s.executors[meta.Name] = executor
+}
+
+// Register installs executor under name. See PatternService.Register.
+func (s *patternService) Register(name string, executor PatternExecutor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.executors[name] = executor
+	if _, ok := s.patterns[name]; !ok {
+		s.patterns[name] = &Pattern{Name: name, Enabled: true}
+	}
+}
+
+func (s *patternService) executorFor(name string) (PatternExecutor, *Pattern, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	executor, ok := s.executors[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("pattern not found: %s", name)
+	}
+	return executor, s.patterns[name], nil
+}
+
+// List returns all registered patterns
+func (s *patternService) List(ctx context.Context) ([]*Pattern, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	patterns := make([]*Pattern, 0, len(s.patterns))
+	for _, p := range s.patterns {
+		patterns = append(patterns, p)
+	}
+	sort.Slice(patterns, func(i, j int) bool { return patterns[i].Name < patterns[j].Name })
+	return patterns, nil
 }
 
 // Get returns a specific pattern by name
 func (s *patternService) Get(ctx context.Context, name string) (*Pattern, error) {
-	s.logger.Debug("Getting pattern", zap.String("name", name))
-	
-	patterns, err := s.List(ctx)
-	if err != nil {
-		return nil, err
-	}
-	
-	for _, p := range patterns {
-		if p.Name == name {
-			return p, nil
-		}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.patterns[name]
+	if !ok {
+		return nil, fmt.Errorf("pattern not found: %s", name)
 	}
-	
-	return nil, fmt.Errorf("pattern not found: %s", name)
+	return p, nil
 }
 
-// Execute runs a pattern with the given input
+// Execute discovers agents eligible for pattern (active, with its required
+// capabilities) and hands them to the pattern's registered PatternExecutor.
 func (s *patternService) Execute(ctx context.Context, pattern string, input interface{}, opts *ExecuteOptions) (*PatternExecution, error) {
-	s.logger.Info("Executing pattern",
-		zap.String("pattern", pattern),
-		zap.Any("input", input),
-		zap.Any("options", opts),
-	)
-	
+	s.logger.Info("Executing pattern", zap.String("pattern", pattern))
+
 	if opts == nil {
 		opts = &ExecuteOptions{}
 	}
-	
-	// Convert input to JSON for transport
-	inputJSON, err := json.Marshal(input)
+
+	executor, meta, err := s.executorFor(pattern)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal input: %w", err)
+		return nil, err
 	}
-	
-	// TODO: Implement gRPC call
-	
-	// Mock implementation
-	execution := &PatternExecution{
-		ID:        generateID(),
-		Pattern:   pattern,
-		Status:    ExecutionStatusRunning,
-		Input:     json.RawMessage(inputJSON),
-		Agents:    []string{"agent-1", "agent-2", "agent-3"},
-		StartTime: timeNow(),
-		Metadata:  opts.Metadata,
-	}
-	
-	// Simulate async execution
-	if !opts.Async {
-		execution.Status = ExecutionStatusCompleted
-		execution.Output = map[string]interface{}{
-			"result": "consensus reached",
-			"confidence": 0.85,
-		}
-		endTime := timeNow()
-		execution.EndTime = &endTime
-		execution.Duration = endTime.Sub(execution.StartTime)
-		execution.Confidence = 0.85
-	}
-	
+
+	var required []string
+	if meta != nil {
+		required = meta.RequiredCapabilities
+	}
+
+	agents, selectionMeta, err := s.eligibleAgents(ctx, pattern, input, required, opts.AgentSelector)
+	if err != nil {
+		return nil, err
+	}
+	if len(selectionMeta) > 0 {
+		if opts.Metadata == nil {
+			opts.Metadata = make(map[string]interface{}, len(selectionMeta))
+		}
+		for k, v := range selectionMeta {
+			opts.Metadata[k] = v
+		}
+	}
+
+	execution, err := executor.Execute(ctx, input, agents, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	s.client.metrics.recordPatternExecution(pattern, execution.Status)
+	s.recordExecution(execution)
 	return execution, nil
 }
 
+// eligibleAgents lists registered agents and narrows them down to those
+// that are active, carry every capability in required, and satisfy
+// selector's ID/MaxCount constraints. The returned map is non-nil only
+// when a registered Selector (selector.Strategy) did the narrowing; its
+// "selector_strategy"/"selector_scores" entries are merged into the
+// execution's Metadata by Execute.
+func (s *patternService) eligibleAgents(ctx context.Context, pattern string, input interface{}, required []string, selector AgentSelector) ([]*AgentInfo, map[string]interface{}, error) {
+	all, err := s.client.agentSvc.List(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+
+	eligible := make([]*AgentInfo, 0, len(all))
+	for _, agent := range all {
+		if agent.Status != AgentStatusActive {
+			continue
+		}
+		if !hasCapabilities(agent.Capabilities, required) {
+			continue
+		}
+		if len(selector.IDs) > 0 && !containsString(selector.IDs, agent.ID) {
+			continue
+		}
+		eligible = append(eligible, agent)
+	}
+
+	if selector.MaxCount <= 0 || len(eligible) <= selector.MaxCount {
+		return eligible, nil, nil
+	}
+
+	if selector.Strategy == "" {
+		return s.stableSubset(eligible, required, selector.MaxCount), nil, nil
+	}
+
+	sel, err := s.resolveSelectorForPattern(string(selector.Strategy))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := SelectRequest{
+		Pattern:      pattern,
+		Input:        input,
+		Capabilities: required,
+		MaxCount:     selector.MaxCount,
+		Scorer:       selector.Scorer,
+	}
+	chosen, err := sel.Select(ctx, eligible, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("select agents for pattern %s: %w", pattern, err)
+	}
+
+	scores := make(map[string]float64, len(chosen))
+	scorer, ok := sel.(ScoredSelector)
+	for _, agent := range chosen {
+		if ok {
+			scores[agent.ID] = scorer.Score(ctx, agent, req)
+		} else {
+			scores[agent.ID] = agent.Confidence
+		}
+	}
+	meta := map[string]interface{}{
+		"selector_strategy": string(selector.Strategy),
+		"selector_scores":   scores,
+	}
+	return chosen, meta, nil
+}
+
+// resolveSelectorForPattern resolves name through the Selector registry,
+// threading in s.stats for the two built-in Selectors that score
+// candidates from rolling execution history rather than a single static
+// AgentInfo.Confidence snapshot.
+func (s *patternService) resolveSelectorForPattern(name string) (Selector, error) {
+	var cfg map[string]interface{}
+	if name == selectorPowerOfTwoChoices || name == selectorExpertiseWeighted {
+		cfg = map[string]interface{}{"stats": s.stats}
+	}
+	return resolveSelector(name, cfg)
+}
+
+// stableSubset narrows eligible down to maxCount agents using the
+// client's consistent-hash ring, keyed by required's first capability, so
+// repeated executions of the same pattern prefer the same agents as the
+// fleet scales instead of whichever agents List happened to return first.
+// It falls back to a prefix truncation when no ring is configured, no
+// capability was required, or the ring hasn't observed an eligible agent
+// yet (e.g. it just registered and WatchPrefix hasn't synced).
+func (s *patternService) stableSubset(eligible []*AgentInfo, required []string, maxCount int) []*AgentInfo {
+	if s.client.ring == nil || len(required) == 0 {
+		return eligible[:maxCount]
+	}
+
+	byID := make(map[string]*AgentInfo, len(eligible))
+	for _, agent := range eligible {
+		byID[agent.ID] = agent
+	}
+
+	subset := make([]*AgentInfo, 0, maxCount)
+	for _, id := range s.client.ring.Get(required[0], len(byID)) {
+		if agent, ok := byID[id]; ok {
+			subset = append(subset, agent)
+			delete(byID, id)
+			if len(subset) == maxCount {
+				return subset
+			}
+		}
+	}
+
+	for _, agent := range eligible {
+		if _, ok := byID[agent.ID]; !ok {
+			continue
+		}
+		subset = append(subset, agent)
+		if len(subset) == maxCount {
+			break
+		}
+	}
+	return subset
+}
+
+// recordExecution stores execution for GetExecution/ListExecutions and
+// notifies any StreamExecutions subscribers. Subscriber sends are
+// non-blocking so a slow watcher can't stall pattern execution.
+func (s *patternService) recordExecution(execution *PatternExecution) {
+	s.executionsMu.Lock()
+	s.executions[execution.ID] = execution
+	subs := append([]chan *PatternExecution(nil), s.subscribers...)
+	s.executionsMu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- execution:
+		default:
+		}
+	}
+}
+
 // GetExecution returns the status of a pattern execution
 func (s *patternService) GetExecution(ctx context.Context, executionID string) (*PatternExecution, error) {
-	s.logger.Debug("Getting execution", zap.String("id", executionID))
-	
-	// TODO: Implement gRPC call
-	
-	// Mock implementation
-	return &PatternExecution{
-		ID:         executionID,
-		Pattern:    "consensus-builder",
-		Status:     ExecutionStatusCompleted,
-		Input:      map[string]interface{}{"task": "analyze sentiment"},
-		Output:     map[string]interface{}{"result": "positive", "confidence": 0.85},
-		Agents:     []string{"agent-1", "agent-2", "agent-3"},
-		StartTime:  timeNow().Add(-5 * timeMinute),
-		EndTime:    &[]time.Time{timeNow()}[0],
-		Duration:   5 * timeMinute,
-		Confidence: 0.85,
-	}, nil
-}
-
-// ListExecutions returns recent pattern executions
+	s.executionsMu.Lock()
+	defer s.executionsMu.Unlock()
+
+	execution, ok := s.executions[executionID]
+	if !ok {
+		return nil, fmt.Errorf("execution not found: %s", executionID)
+	}
+	return execution, nil
+}
+
+// ListExecutions returns recent pattern executions, most recent first
 func (s *patternService) ListExecutions(ctx context.Context, limit int) ([]*PatternExecution, error) {
-	s.logger.Debug("Listing executions", zap.Int("limit", limit))
-	
-	// TODO: Implement gRPC call
-	
-	// Mock implementation
-	executions := make([]*PatternExecution, 0, limit)
-	for i := 0; i < limit && i < 10; i++ {
-		execution := &PatternExecution{
-			ID:         generateID(),
-			Pattern:    "consensus-builder",
-			Status:     ExecutionStatusCompleted,
-			Input:      map[string]interface{}{"task": fmt.Sprintf("task-%d", i)},
-			Output:     map[string]interface{}{"result": "success"},
-			Agents:     []string{"agent-1", "agent-2"},
-			StartTime:  timeNow().Add(-time.Duration(i) * timeHour),
-			EndTime:    &[]time.Time{timeNow().Add(-time.Duration(i) * timeHour).Add(5 * timeMinute)}[0],
-			Duration:   5 * timeMinute,
-			Confidence: 0.8 + float64(i)*0.01,
-		}
+	s.executionsMu.Lock()
+	defer s.executionsMu.Unlock()
+
+	executions := make([]*PatternExecution, 0, len(s.executions))
+	for _, execution := range s.executions {
 		executions = append(executions, execution)
 	}
-	
+	sort.Slice(executions, func(i, j int) bool {
+		return executions[i].StartTime.After(executions[j].StartTime)
+	})
+
+	if limit > 0 && len(executions) > limit {
+		executions = executions[:limit]
+	}
 	return executions, nil
 }
 
-// StreamExecutions streams pattern execution updates
+// StreamExecutions streams pattern execution updates as Execute records them
 func (s *patternService) StreamExecutions(ctx context.Context) (<-chan *PatternExecution, error) {
 	s.logger.Debug("Streaming executions")
-	
-	// TODO: Implement gRPC streaming
-	
-	// Mock implementation
-	ch := make(chan *PatternExecution)
-	
+
+	ch := make(chan *PatternExecution, 16)
+
+	s.executionsMu.Lock()
+	s.subscribers = append(s.subscribers, ch)
+	s.executionsMu.Unlock()
+
 	go func() {
-		defer close(ch)
-		
-		ticker := timeTicker(5 * timeSecond)
-		defer ticker.Stop()
-		
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				execution := &PatternExecution{
-					ID:         generateID(),
-					Pattern:    "stream-test",
-					Status:     ExecutionStatusRunning,
-					Input:      map[string]interface{}{"streaming": true},
-					Agents:     []string{"agent-1"},
-					StartTime:  timeNow(),
-					Confidence: 0.75,
-				}
-				
-				select {
-				case ch <- execution:
-				case <-ctx.Done():
-					return
-				}
+		<-ctx.Done()
+
+		s.executionsMu.Lock()
+		defer s.executionsMu.Unlock()
+		for i, sub := range s.subscribers {
+			if sub == ch {
+				s.subscribers = append(s.subscribers[:i], s.subscribers[i+1:]...)
+				break
 			}
 		}
+		close(ch)
 	}()
-	
+
 	return ch, nil
-}
\ No newline at end of file
+}
+
+// SubmitJob runs spec as a batch workload. See PatternService.SubmitJob.
+func (s *patternService) SubmitJob(ctx context.Context, spec *JobSpec) (*JobExecution, error) {
+	if spec.ActiveDeadlineSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(spec.ActiveDeadlineSeconds)*time.Second)
+		defer cancel()
+	}
+
+	job := &JobExecution{
+		ID:        generateID(),
+		Spec:      spec,
+		Phase:     JobPending,
+		StartTime: timeNow(),
+	}
+	s.recordJob(job, nil)
+
+	if err := s.awaitDependencies(ctx, spec.DependsOn); err != nil {
+		return s.finishJob(job, JobFailed, nil, err), nil
+	}
+
+	job.Phase = JobRunning
+	s.recordJob(job, nil)
+
+	execution, err := s.runJobWithRetry(ctx, job, spec)
+	if err != nil {
+		return s.finishJob(job, JobFailed, execution, err), nil
+	}
+	return s.finishJob(job, JobSucceeded, execution, nil), nil
+}
+
+// awaitDependencies waits, in order, for each job ID in dependsOn to reach
+// a terminal phase, returning an error at the first one that failed (or
+// doesn't exist) without waiting for the rest.
+func (s *patternService) awaitDependencies(ctx context.Context, dependsOn []string) error {
+	for _, id := range dependsOn {
+		done, ok := s.jobDoneCh(id)
+		if !ok {
+			return fmt.Errorf("dependency job not found: %s", id)
+		}
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		dep, ok := s.jobByID(id)
+		if !ok || dep.Phase != JobSucceeded {
+			return fmt.Errorf("dependency job %s did not succeed", id)
+		}
+	}
+	return nil
+}
+
+// runJobWithRetry calls Execute up to spec.BackoffLimit+1 times, backing
+// off exponentially (with jitter) between attempts, stopping early if ctx
+// is cancelled.
+func (s *patternService) runJobWithRetry(ctx context.Context, job *JobExecution, spec *JobSpec) (*PatternExecution, error) {
+	opts := spec.Options
+	if opts == nil {
+		opts = &ExecuteOptions{}
+	}
+
+	var lastErr error
+	attempts := spec.BackoffLimit + 1
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepBackoff(ctx, attempt); err != nil {
+				return nil, err
+			}
+		}
+
+		job.Attempts++
+		s.recordJob(job, nil)
+
+		execution, err := s.Execute(ctx, spec.Pattern, spec.Input, opts)
+		if err == nil {
+			return execution, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("job failed after %d attempt(s): %w", job.Attempts, lastErr)
+}
+
+// sleepBackoff waits out attempt's exponential-with-jitter backoff delay,
+// or returns ctx's error if it's cancelled first.
+func sleepBackoff(ctx context.Context, attempt int) error {
+	delay := time.Duration(1<<uint(attempt-1)) * 500 * time.Millisecond
+	if delay > 30*time.Second {
+		delay = 30 * time.Second
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay) / 2))
+
+	select {
+	case <-time.After(delay + jitter):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// finishJob sets job's terminal phase/result and broadcasts it, returning
+// job for the caller's convenience.
+func (s *patternService) finishJob(job *JobExecution, phase JobPhase, execution *PatternExecution, err error) *JobExecution {
+	end := timeNow()
+	job.Phase = phase
+	job.Execution = execution
+	job.EndTime = &end
+	if err != nil {
+		job.Error = err.Error()
+	}
+	s.recordJob(job, err)
+	return job
+}
+
+// recordJob stores job, notifies its subscribers, and — once job reaches
+// a terminal phase — closes its done channel so awaitDependencies and any
+// concurrent StreamJob callers observe completion.
+func (s *patternService) recordJob(job *JobExecution, streamErr error) {
+	s.jobsMu.Lock()
+	s.jobs[job.ID] = job
+	if _, ok := s.jobDone[job.ID]; !ok {
+		s.jobDone[job.ID] = make(chan struct{})
+	}
+	subs := append([]chan *JobEvent(nil), s.jobSubs[job.ID]...)
+	terminal := job.Phase == JobSucceeded || job.Phase == JobFailed
+	var done chan struct{}
+	if terminal {
+		done = s.jobDone[job.ID]
+	}
+	s.jobsMu.Unlock()
+
+	event := &JobEvent{Job: job, Err: streamErr}
+	for _, ch := range subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+
+	if done != nil {
+		select {
+		case <-done:
+		default:
+			close(done)
+		}
+	}
+}
+
+func (s *patternService) jobByID(id string) (*JobExecution, bool) {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+func (s *patternService) jobDoneCh(id string) (chan struct{}, bool) {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+	ch, ok := s.jobDone[id]
+	return ch, ok
+}
+
+// StreamJob streams phase transitions for id. See PatternService.StreamJob.
+func (s *patternService) StreamJob(ctx context.Context, id string) (<-chan *JobEvent, error) {
+	job, ok := s.jobByID(id)
+	if !ok {
+		return nil, fmt.Errorf("job not found: %s", id)
+	}
+
+	ch := make(chan *JobEvent, 16)
+
+	s.jobsMu.Lock()
+	s.jobSubs[id] = append(s.jobSubs[id], ch)
+	s.jobsMu.Unlock()
+
+	// Replay the current phase immediately so a caller subscribing after
+	// the job already finished still observes its terminal state.
+	ch <- &JobEvent{Job: job}
+
+	done, _ := s.jobDoneCh(id)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+
+		s.jobsMu.Lock()
+		defer s.jobsMu.Unlock()
+		subs := s.jobSubs[id]
+		for i, sub := range subs {
+			if sub == ch {
+				s.jobSubs[id] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+// StreamAnalyze opens a StreamAnalyze call against every agent in agents and
+// multiplexes their incremental AgentResult updates onto a single channel.
+// Cancelling ctx halts every in-flight agent stream.
+func (s *patternService) StreamAnalyze(ctx context.Context, agents []*AgentInfo, task string, data interface{}) (<-chan *AgentResult, error) {
+	s.logger.Info("Streaming analyze across agents",
+		zap.String("task", task),
+		zap.Int("agents", len(agents)),
+	)
+
+	dataStruct, err := structFromData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *AgentResult)
+	var wg sync.WaitGroup
+
+	for _, agentInfo := range agents {
+		agentInfo := agentInfo
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.streamAnalyzeOne(ctx, agentInfo, task, dataStruct, out); err != nil {
+				s.logger.Warn("StreamAnalyze failed for agent",
+					zap.String("agent", agentInfo.ID), zap.Error(err))
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out, nil
+}
+
+func (s *patternService) streamAnalyzeOne(ctx context.Context, agentInfo *AgentInfo, task string, data *structpb.Struct, out chan<- *AgentResult) error {
+	client, err := s.client.clientSet.AgentClient(agentInfo.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	stream, err := client.StreamAnalyze(ctx, &generated.AgentRequest{
+		TaskDescription: task,
+		Data:            data,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case out <- agentResultFromConfidence(resp):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// structFromData converts data (expected to be a map[string]interface{} or
+// JSON-marshalable equivalent) into a structpb.Struct suitable for an
+// AgentRequest. A nil data yields a nil Struct.
+func structFromData(data interface{}) (*structpb.Struct, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	asMap, ok := data.(map[string]interface{})
+	if !ok {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal data: %w", err)
+		}
+		if err := json.Unmarshal(raw, &asMap); err != nil {
+			return nil, fmt.Errorf("data must be a JSON object: %w", err)
+		}
+	}
+
+	return structpb.NewStruct(asMap)
+}
+
+// agentResultFromConfidence converts a generated.ConfidenceResult received
+// from a peer agent into the SDK's AgentResult type.
+func agentResultFromConfidence(resp *generated.ConfidenceResult) *AgentResult {
+	var value interface{}
+	if resp.ValueJson != "" {
+		_ = json.Unmarshal([]byte(resp.ValueJson), &value)
+	}
+
+	return &AgentResult{
+		Value:         value,
+		Confidence:    resp.Confidence,
+		Reasoning:     resp.Reasoning,
+		Uncertainties: resp.Uncertainties,
+		Metadata:      resp.Metadata,
+		AgentID:       resp.AgentId,
+	}
+}
+
+// analyzeOn dials agent through clientSet and issues a single Analyze RPC.
+func analyzeOn(ctx context.Context, clientSet *RegistryClientSet, agent *AgentInfo, task string, data interface{}) (*AgentResult, error) {
+	client, err := clientSet.AgentClient(agent.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	dataStruct, err := structFromData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Analyze(ctx, &generated.AgentRequest{
+		TaskDescription: task,
+		Data:            dataStruct,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return agentResultFromConfidence(resp), nil
+}
+
+// fanOutAnalyze issues an Analyze RPC against every agent with the same
+// input, returning the results that succeeded and the errors from those
+// that didn't.
+func fanOutAnalyze(ctx context.Context, clientSet *RegistryClientSet, agents []*AgentInfo, task string, input interface{}) ([]*AgentResult, []error) {
+	shards := make([]interface{}, len(agents))
+	for i := range agents {
+		shards[i] = input
+	}
+	return fanOutAnalyzeShards(ctx, clientSet, agents, task, shards)
+}
+
+// fanOutAnalyzeShards pairs agents[i] with shards[i] and issues their
+// Analyze RPCs concurrently, pairing up to the shorter of the two slices.
+func fanOutAnalyzeShards(ctx context.Context, clientSet *RegistryClientSet, agents []*AgentInfo, task string, shards []interface{}) ([]*AgentResult, []error) {
+	n := len(shards)
+	if len(agents) < n {
+		n = len(agents)
+	}
+
+	type outcome struct {
+		result *AgentResult
+		err    error
+	}
+	outcomes := make([]outcome, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := analyzeOn(ctx, clientSet, agents[i], task, shards[i])
+			outcomes[i] = outcome{result: result, err: err}
+		}()
+	}
+	wg.Wait()
+
+	results := make([]*AgentResult, 0, n)
+	var errs []error
+	for _, o := range outcomes {
+		if o.err != nil {
+			errs = append(errs, o.err)
+			continue
+		}
+		results = append(results, o.result)
+	}
+	return results, errs
+}
+
+// streamSingleShot adapts a blocking Execute call into a PatternEvent
+// channel carrying a single completion (or error) event.
+func streamSingleShot(ctx context.Context, execute func() (*PatternExecution, error)) <-chan PatternEvent {
+	ch := make(chan PatternEvent, 1)
+	go func() {
+		defer close(ch)
+		execution, err := execute()
+		select {
+		case ch <- PatternEvent{Execution: execution, Err: err}:
+		case <-ctx.Done():
+		}
+	}()
+	return ch
+}
+
+func newExecution(pattern string, input interface{}, agents []*AgentInfo, opts *ExecuteOptions) *PatternExecution {
+	ids := make([]string, len(agents))
+	for i, a := range agents {
+		ids[i] = a.ID
+	}
+
+	var metadata map[string]interface{}
+	if opts != nil {
+		metadata = opts.Metadata
+	}
+
+	return &PatternExecution{
+		ID:        generateID(),
+		Pattern:   pattern,
+		Status:    ExecutionStatusRunning,
+		Input:     input,
+		Agents:    ids,
+		StartTime: timeNow(),
+		Metadata:  metadata,
+	}
+}
+
+func completeExecution(execution *PatternExecution, output interface{}, confidence float64) *PatternExecution {
+	end := timeNow()
+	execution.Status = ExecutionStatusCompleted
+	execution.Output = output
+	execution.Confidence = confidence
+	execution.EndTime = &end
+	execution.Duration = end.Sub(execution.StartTime)
+	return execution
+}
+
+func failExecution(execution *PatternExecution, err error) *PatternExecution {
+	end := timeNow()
+	execution.Status = ExecutionStatusFailed
+	execution.Error = err.Error()
+	execution.EndTime = &end
+	execution.Duration = end.Sub(execution.StartTime)
+	return execution
+}
+
+// consensusBuilderExecutor implements the built-in "consensus-builder"
+// pattern: every agent analyzes the same input, and the result whose
+// confidence-weighted votes clear threshold wins.
+type consensusBuilderExecutor struct {
+	clientSet  *RegistryClientSet
+	minAgents  int
+	threshold  float64
+	calibrator *ConfidenceCalibrator
+}
+
+func (e *consensusBuilderExecutor) Execute(ctx context.Context, input interface{}, agents []*AgentInfo, opts *ExecuteOptions) (*PatternExecution, error) {
+	if len(agents) < e.minAgents {
+		return nil, fmt.Errorf("consensus-builder requires at least %d agents, got %d", e.minAgents, len(agents))
+	}
+
+	execution := newExecution("consensus-builder", input, agents, opts)
+
+	results, errs := fanOutAnalyze(ctx, e.clientSet, agents, "consensus", input)
+	if len(results) == 0 {
+		return failExecution(execution, fmt.Errorf("all agents failed: %v", errs)), nil
+	}
+
+	value, confidence, agreed := weightedConsensus(results, e.threshold)
+	if !agreed {
+		return failExecution(execution, fmt.Errorf("no consensus: agreement below threshold %.2f", e.threshold)), nil
+	}
+
+	e.calibrateAgainstConsensus(results, confidence)
+
+	return completeExecution(execution, value, confidence), nil
+}
+
+// calibrateAgainstConsensus feeds every contributing agent's self-reported
+// confidence back into e.calibrator against the consensus this execution
+// actually converged on, closing the loop between the extractor/aggregator
+// subsystem and the running agent fleet: an agent that's consistently
+// over- or under-confident relative to its peers accumulates a Bias that
+// later Calibrate calls correct for.
+func (e *consensusBuilderExecutor) calibrateAgainstConsensus(results []*AgentResult, consensus float64) {
+	if e.calibrator == nil {
+		return
+	}
+	for _, r := range results {
+		if r.AgentID == "" {
+			continue
+		}
+		e.calibrator.Observe(r.AgentID, r.Confidence, consensus)
+	}
+}
+
+func (e *consensusBuilderExecutor) Stream(ctx context.Context, input interface{}, agents []*AgentInfo, opts *ExecuteOptions) <-chan PatternEvent {
+	return streamSingleShot(ctx, func() (*PatternExecution, error) {
+		return e.Execute(ctx, input, agents, opts)
+	})
+}
+
+// weightedConsensus groups results by their (stringified) Value, weighting
+// each vote by its own Confidence, and returns the heaviest group's value
+// along with the weighted-average confidence across all votes. agreed is
+// true only if the winning group's share of total weight clears threshold.
+func weightedConsensus(results []*AgentResult, threshold float64) (value interface{}, confidence float64, agreed bool) {
+	type group struct {
+		value  interface{}
+		weight float64
+	}
+	groups := make(map[string]*group)
+
+	var totalWeight float64
+	confidences := make([]float64, len(results))
+	for i, r := range results {
+		key := fmt.Sprintf("%v", r.Value)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{value: r.Value}
+			groups[key] = g
+		}
+		g.weight += r.Confidence
+		totalWeight += r.Confidence
+		confidences[i] = r.Confidence
+	}
+	if totalWeight == 0 {
+		totalWeight = float64(len(results))
+	}
+
+	var best *group
+	for _, g := range groups {
+		if best == nil || g.weight > best.weight {
+			best = g
+		}
+	}
+
+	confidence = (&ConfidenceAggregator{}).Combine(confidences, "weighted_avg", confidences)
+	return best.value, confidence, best.weight/totalWeight >= threshold
+}
+
+// mapReduceExecutor implements the built-in "map-reduce" pattern: input is
+// sharded across agents, each shard is analyzed independently, and the
+// per-shard results are combined with ExecuteOptions.Reducer.
+type mapReduceExecutor struct {
+	clientSet *RegistryClientSet
+	minAgents int
+}
+
+func (e *mapReduceExecutor) Execute(ctx context.Context, input interface{}, agents []*AgentInfo, opts *ExecuteOptions) (*PatternExecution, error) {
+	if len(agents) < e.minAgents {
+		return nil, fmt.Errorf("map-reduce requires at least %d agents, got %d", e.minAgents, len(agents))
+	}
+
+	execution := newExecution("map-reduce", input, agents, opts)
+
+	shards, err := shardInput(input, len(agents))
+	if err != nil {
+		return failExecution(execution, err), nil
+	}
+
+	results, errs := fanOutAnalyzeShards(ctx, e.clientSet, agents, "map-reduce", shards)
+	if len(results) == 0 {
+		return failExecution(execution, fmt.Errorf("all shards failed: %v", errs)), nil
+	}
+
+	reduce := opts.Reducer
+	if reduce == nil {
+		reduce = collectValues
+	}
+	output, err := reduce(results)
+	if err != nil {
+		return failExecution(execution, err), nil
+	}
+
+	confidence := (&ConfidenceAggregator{}).Combine(confidencesOf(results), "avg", nil)
+	return completeExecution(execution, output, confidence), nil
+}
+
+func (e *mapReduceExecutor) Stream(ctx context.Context, input interface{}, agents []*AgentInfo, opts *ExecuteOptions) <-chan PatternEvent {
+	return streamSingleShot(ctx, func() (*PatternExecution, error) {
+		return e.Execute(ctx, input, agents, opts)
+	})
+}
+
+// shardInput splits a slice input into at most n nearly-equal shards. Non-
+// slice input is treated as a single shard handed to one agent.
+func shardInput(input interface{}, n int) ([]interface{}, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("cannot shard input across zero agents")
+	}
+
+	rv := reflect.ValueOf(input)
+	if rv.Kind() != reflect.Slice {
+		return []interface{}{input}, nil
+	}
+
+	total := rv.Len()
+	if total == 0 {
+		return nil, fmt.Errorf("cannot shard empty input")
+	}
+
+	shardCount := n
+	if total < shardCount {
+		shardCount = total
+	}
+
+	shards := make([]interface{}, shardCount)
+	base, rem := total/shardCount, total%shardCount
+	idx := 0
+	for i := 0; i < shardCount; i++ {
+		size := base
+		if i < rem {
+			size++
+		}
+		shard := make([]interface{}, size)
+		for j := 0; j < size; j++ {
+			shard[j] = rv.Index(idx).Interface()
+			idx++
+		}
+		shards[i] = shard
+	}
+	return shards, nil
+}
+
+func collectValues(results []*AgentResult) (interface{}, error) {
+	values := make([]interface{}, len(results))
+	for i, r := range results {
+		values[i] = r.Value
+	}
+	return values, nil
+}
+
+func confidencesOf(results []*AgentResult) []float64 {
+	confidences := make([]float64, len(results))
+	for i, r := range results {
+		confidences[i] = r.Confidence
+	}
+	return confidences
+}
@@ -0,0 +1,311 @@
+package parallax
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfidenceAction is the enforcement behavior a matched ConfidenceRule
+// triggers.
+type ConfidenceAction string
+
+const (
+	// ActionDeny rejects the result outright, like RequireMinimumConfidence.
+	ActionDeny ConfidenceAction = "deny"
+	// ActionWarn attaches a warning to AgentResult.Metadata and logs via
+	// zap, but still returns the result.
+	ActionWarn ConfidenceAction = "warn"
+	// ActionAudit emits an AuditEvent to the configured AuditSink and
+	// returns the result unchanged.
+	ActionAudit ConfidenceAction = "audit"
+	// ActionRecalibrate runs the result's confidence through
+	// ConfidenceAggregator.Calibrate using the rule's Calibration.
+	ActionRecalibrate ConfidenceAction = "recalibrate"
+)
+
+// ConfidenceRule is one scoped confidence-enforcement rule within a
+// ConfidencePolicy.
+type ConfidenceRule struct {
+	// Scope selects which (agent, task) pairs this rule applies to, as
+	// "capability=<name>", "task=<name>", or "agent=<id>". Empty matches
+	// everything, letting a policy end with a catch-all rule.
+	Scope string `yaml:"scope" json:"scope"`
+
+	// Action governs what happens once this rule matches.
+	Action ConfidenceAction `yaml:"action" json:"action"`
+
+	// Min is the confidence floor deny/warn compare the result against.
+	// Ignored by audit and recalibrate.
+	Min float64 `yaml:"min" json:"min"`
+
+	// Calibration is the CalibrationData a recalibrate rule applies via
+	// ConfidenceAggregator.Calibrate. Ignored by every other action.
+	Calibration *CalibrationData `yaml:"calibration,omitempty" json:"calibration,omitempty"`
+}
+
+// matchesScope reports whether r applies to agent running task.
+func (r *ConfidenceRule) matchesScope(agent *AgentInfo, task string) bool {
+	if r.Scope == "" {
+		return true
+	}
+
+	key, value, ok := strings.Cut(r.Scope, "=")
+	if !ok {
+		return false
+	}
+
+	switch key {
+	case "capability":
+		return agent != nil && containsString(agent.Capabilities, value)
+	case "task":
+		return task == value
+	case "agent":
+		return agent != nil && agent.ID == value
+	default:
+		return false
+	}
+}
+
+// ConfidencePolicy is an ordered list of ConfidenceRules, replacing
+// RequireMinimumConfidence's single global floor with scoped rules a
+// fleet with many capabilities and callers can tune independently.
+type ConfidencePolicy []ConfidenceRule
+
+// match returns the first rule in p whose Scope matches agent and task,
+// the way a firewall or admission policy evaluates rules top to bottom,
+// or nil if none do.
+func (p ConfidencePolicy) match(agent *AgentInfo, task string) *ConfidenceRule {
+	for i := range p {
+		if p[i].matchesScope(agent, task) {
+			return &p[i]
+		}
+	}
+	return nil
+}
+
+// PolicyFromYAML parses a ConfidencePolicy from YAML, the format
+// operators hot-reload policies from without rebuilding the agent binary.
+func PolicyFromYAML(data []byte) (ConfidencePolicy, error) {
+	var policy ConfidencePolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("parse confidence policy: %w", err)
+	}
+	return policy, nil
+}
+
+// toYAML serializes p, the form agentService.Register stores a per-agent
+// default ConfidencePolicy under PolicyMetadataKey in.
+func (p ConfidencePolicy) toYAML() (string, error) {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("encode confidence policy: %w", err)
+	}
+	return string(data), nil
+}
+
+// AuditEvent is the record an ActionAudit rule emits to an AuditSink.
+type AuditEvent struct {
+	AgentID    string
+	Task       string
+	Confidence float64
+	Rule       ConfidenceRule
+	Timestamp  time.Time
+
+	// Evidence is the per-token evidence behind result.Reasoning's
+	// contribution to Confidence, from builtinLexicon.score — the same
+	// evidence ConfidenceExtractor.ExtractWithExplanation would return for
+	// this text. Empty when Reasoning carries no lexicon matches.
+	Evidence []TokenEvidence
+}
+
+// AuditSink receives AuditEvents from ActionAudit rule matches. Pass a
+// *PolicyEvaluationTracker to also have audit matches show up in
+// agentService.StreamAgents's PolicyEvaluationSummary.
+type AuditSink interface {
+	Audit(ctx context.Context, event AuditEvent)
+}
+
+// PolicyMetadataKey is the AgentRegistration label agentService.Register
+// serializes AgentInfo.Policy into, and agentFromRegistration parses it
+// back out of.
+const PolicyMetadataKey = "confidence_policy"
+
+// WithConfidencePolicy wraps analyzeFunc, evaluating policy's rules (in
+// order, first match wins) against every result agent produces for a
+// task, and replaces RequireMinimumConfidence for agents that need more
+// than one global floor. logger receives ActionWarn's structured log
+// line; sink (nil-able) receives ActionAudit's events; tracker (nil-able)
+// records every match's outcome regardless of action, so a control
+// plane sharing the same tracker can report it back through
+// agentService.StreamAgents.
+func WithConfidencePolicy(agent *AgentInfo, policy ConfidencePolicy, logger *zap.Logger, sink AuditSink, tracker *PolicyEvaluationTracker, analyzeFunc func(ctx context.Context, task string, data interface{}) (*AgentResult, error)) func(ctx context.Context, task string, data interface{}) (*AgentResult, error) {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return func(ctx context.Context, task string, data interface{}) (*AgentResult, error) {
+		result, err := analyzeFunc(ctx, task, data)
+		if err != nil {
+			return nil, err
+		}
+
+		rule := policy.match(agent, task)
+		if tracker != nil {
+			tracker.Record(agent.GetID(), rule, result.Confidence)
+		}
+		if rule == nil {
+			return result, nil
+		}
+
+		switch rule.Action {
+		case ActionDeny:
+			if result.Confidence < rule.Min {
+				return nil, fmt.Errorf("confidence %.2f below policy minimum %.2f for scope %q", result.Confidence, rule.Min, rule.Scope)
+			}
+
+		case ActionWarn:
+			if result.Confidence < rule.Min {
+				_, evidence := builtinLexicon.score(result.Reasoning)
+				logger.Warn("confidence policy warning",
+					zap.String("scope", rule.Scope),
+					zap.Float64("confidence", result.Confidence),
+					zap.Float64("min", rule.Min),
+					zap.String("evidence", citeEvidence(evidence)),
+				)
+				if result.Metadata == nil {
+					result.Metadata = make(map[string]string)
+				}
+				msg := fmt.Sprintf("%s: confidence %.2f below %.2f", rule.Scope, result.Confidence, rule.Min)
+				if cited := citeEvidence(evidence); cited != "" {
+					msg += " (" + cited + ")"
+				}
+				result.Metadata["confidence_policy_warning"] = msg
+			}
+
+		case ActionAudit:
+			if sink != nil {
+				_, evidence := builtinLexicon.score(result.Reasoning)
+				sink.Audit(ctx, AuditEvent{
+					AgentID:    agent.GetID(),
+					Task:       task,
+					Confidence: result.Confidence,
+					Rule:       *rule,
+					Timestamp:  timeNow(),
+					Evidence:   evidence,
+				})
+			}
+
+		case ActionRecalibrate:
+			result.Confidence = (&ConfidenceAggregator{}).Calibrate(result.Confidence, rule.Calibration)
+		}
+
+		return result, nil
+	}
+}
+
+// GetID returns a.ID, or "" for a nil *AgentInfo, so WithConfidencePolicy
+// doesn't need a nil check at every call site.
+func (a *AgentInfo) GetID() string {
+	if a == nil {
+		return ""
+	}
+	return a.ID
+}
+
+// PolicyEvaluationSummary reports the most recent ConfidencePolicy rule
+// match recorded for one agent, for agentService.StreamAgents to attach
+// to that agent's AgentInfo.PolicyEvaluation.
+type PolicyEvaluationSummary struct {
+	AgentID string
+
+	// Blocking/Warning hold the Scope of the most recently matched
+	// deny/warn rule, if the last recorded evaluation was one. Both are
+	// empty once an evaluation matches no rule, or matches one whose
+	// Action is audit or recalibrate.
+	Blocking string
+	Warning  string
+
+	LastUpdated time.Time
+}
+
+// PolicyEvaluationTracker records every ConfidencePolicy rule match
+// WithConfidencePolicy evaluates, keyed by agent ID, so a control plane
+// can observe via agentService.StreamAgents which scopes are currently
+// blocking or warning for a given agent. It also implements AuditSink,
+// so passing the same tracker as both WithConfidencePolicy's tracker
+// argument and its sink argument means audit-action matches show up in
+// PolicyEvaluationSummary too.
+type PolicyEvaluationTracker struct {
+	mu      sync.Mutex
+	byAgent map[string]*PolicyEvaluationSummary
+}
+
+// NewPolicyEvaluationTracker creates an empty PolicyEvaluationTracker.
+func NewPolicyEvaluationTracker() *PolicyEvaluationTracker {
+	return &PolicyEvaluationTracker{byAgent: make(map[string]*PolicyEvaluationSummary)}
+}
+
+// Record updates agentID's summary with rule's outcome against
+// confidence (or clears it, if rule is nil because no rule matched). A
+// matched deny/warn rule only sets Blocking/Warning when confidence
+// actually falls below rule.Min — matching a rule's Scope isn't itself
+// an outcome, since WithConfidencePolicy still allows the result through
+// whenever confidence clears the threshold. It reflects only the most
+// recent evaluation, not a historical accumulation across scopes.
+func (t *PolicyEvaluationTracker) Record(agentID string, rule *ConfidenceRule, confidence float64) {
+	if agentID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summary, ok := t.byAgent[agentID]
+	if !ok {
+		summary = &PolicyEvaluationSummary{AgentID: agentID}
+		t.byAgent[agentID] = summary
+	}
+
+	summary.Blocking = ""
+	summary.Warning = ""
+	summary.LastUpdated = timeNow()
+
+	if rule == nil {
+		return
+	}
+	switch rule.Action {
+	case ActionDeny:
+		if confidence < rule.Min {
+			summary.Blocking = rule.Scope
+		}
+	case ActionWarn:
+		if confidence < rule.Min {
+			summary.Warning = rule.Scope
+		}
+	}
+}
+
+// Audit implements AuditSink by recording event's rule the same way
+// Record does for deny/warn matches.
+func (t *PolicyEvaluationTracker) Audit(ctx context.Context, event AuditEvent) {
+	t.Record(event.AgentID, &event.Rule, event.Confidence)
+}
+
+// Summary returns a copy of agentID's current PolicyEvaluationSummary, or
+// false if no evaluation has been recorded for it yet.
+func (t *PolicyEvaluationTracker) Summary(agentID string) (PolicyEvaluationSummary, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.byAgent[agentID]
+	if !ok {
+		return PolicyEvaluationSummary{}, false
+	}
+	return *s, true
+}
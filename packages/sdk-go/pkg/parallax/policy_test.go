@@ -0,0 +1,86 @@
+package parallax
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestPolicyEvaluationTrackerRecordRequiresThresholdBreach guards against
+// the bug this series' earlier fix commit (HaruHunab1320/parallax#chunk2-2)
+// addressed: Record must only mark Blocking/Warning when confidence
+// actually falls below rule.Min, not merely because a deny/warn-scoped
+// rule matched.
+func TestPolicyEvaluationTrackerRecordRequiresThresholdBreach(t *testing.T) {
+	tracker := NewPolicyEvaluationTracker()
+	rule := &ConfidenceRule{Scope: "capability=summarize", Action: ActionDeny, Min: 0.8}
+
+	tracker.Record("agent-1", rule, 0.9)
+	summary, ok := tracker.Summary("agent-1")
+	if !ok {
+		t.Fatalf("Summary(%q) not found after Record", "agent-1")
+	}
+	if summary.Blocking != "" {
+		t.Fatalf("Blocking = %q, want empty: a matched deny rule whose confidence clears Min must not block", summary.Blocking)
+	}
+
+	tracker.Record("agent-1", rule, 0.5)
+	summary, _ = tracker.Summary("agent-1")
+	if summary.Blocking != rule.Scope {
+		t.Fatalf("Blocking = %q, want %q once confidence falls below Min", summary.Blocking, rule.Scope)
+	}
+}
+
+func TestPolicyEvaluationTrackerRecordWarn(t *testing.T) {
+	tracker := NewPolicyEvaluationTracker()
+	rule := &ConfidenceRule{Scope: "task=classify", Action: ActionWarn, Min: 0.5}
+
+	tracker.Record("agent-1", rule, 0.4)
+	summary, _ := tracker.Summary("agent-1")
+	if summary.Warning != rule.Scope {
+		t.Fatalf("Warning = %q, want %q", summary.Warning, rule.Scope)
+	}
+	if summary.Blocking != "" {
+		t.Fatalf("Blocking = %q, want empty for a warn rule", summary.Blocking)
+	}
+}
+
+// TestPolicyEvaluationTrackerRecordClearsPreviousOutcome checks that a
+// later evaluation with no matched rule (or one that doesn't breach its
+// threshold) clears a previously recorded Blocking/Warning, since Record
+// reflects only the most recent evaluation.
+func TestPolicyEvaluationTrackerRecordClearsPreviousOutcome(t *testing.T) {
+	tracker := NewPolicyEvaluationTracker()
+	rule := &ConfidenceRule{Scope: "capability=summarize", Action: ActionDeny, Min: 0.8}
+
+	tracker.Record("agent-1", rule, 0.5)
+	if summary, _ := tracker.Summary("agent-1"); summary.Blocking == "" {
+		t.Fatalf("expected Blocking to be set before the clearing Record call")
+	}
+
+	tracker.Record("agent-1", nil, 0.9)
+	summary, _ := tracker.Summary("agent-1")
+	if summary.Blocking != "" {
+		t.Fatalf("Blocking = %q, want empty once a later evaluation matches no rule", summary.Blocking)
+	}
+}
+
+func TestWithConfidencePolicyDenyBelowMinReturnsError(t *testing.T) {
+	policy := ConfidencePolicy{{Scope: "", Action: ActionDeny, Min: 0.8}}
+	agent := &AgentInfo{ID: "agent-1"}
+	tracker := NewPolicyEvaluationTracker()
+
+	wrapped := WithConfidencePolicy(agent, policy, zap.NewNop(), nil, tracker, func(_ context.Context, _ string, _ interface{}) (*AgentResult, error) {
+		return &AgentResult{Confidence: 0.5}, nil
+	})
+
+	if _, err := wrapped(context.Background(), "task", nil); err == nil {
+		t.Fatalf("expected an error when confidence falls below a deny rule's Min")
+	}
+
+	summary, _ := tracker.Summary("agent-1")
+	if summary.Blocking != policy[0].Scope {
+		t.Fatalf("Blocking = %q, want %q: Record marks the breached deny rule's scope", summary.Blocking, policy[0].Scope)
+	}
+}
@@ -0,0 +1,189 @@
+package parallax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// defaultUnhealthyCooldown and defaultProbeInterval tune registryBalancer
+// when GrpcAgent doesn't override them: a failed endpoint sits out for 30s,
+// and benched endpoints are re-probed for recovery every 15s rather than
+// waiting out the full cooldown.
+const (
+	defaultUnhealthyCooldown = 30 * time.Second
+	defaultProbeInterval     = 15 * time.Second
+)
+
+// registryBalancerEndpoint tracks one registry endpoint's health.
+type registryBalancerEndpoint struct {
+	addr           string
+	unhealthyUntil time.Time
+}
+
+// registryBalancer pins GrpcAgent's Register/Renew/Unregister calls to one
+// healthy registry endpoint out of a configured list, modeled on etcd
+// clientv3's balancer: calls stick to the active endpoint until one fails
+// for a reason other than context cancellation, at which point that
+// endpoint is benched for a cooldown and the balancer fails over to the
+// next healthy one. Benched endpoints are re-probed on probeInterval via
+// the standard gRPC health protocol and rejoin the pool as soon as they
+// respond SERVING, rather than waiting out the full cooldown.
+//
+// It owns a RegistryClientSet so every endpoint's connection is dialed
+// once and shared across Register/Renew/Unregister, replacing GrpcAgent's
+// old practice of a fresh grpc.NewClient per call.
+type registryBalancer struct {
+	clientSet *RegistryClientSet
+
+	cooldown      time.Duration
+	probeInterval time.Duration
+
+	mu        sync.Mutex
+	endpoints []*registryBalancerEndpoint
+	active    string // "" means no endpoint is currently pinned
+}
+
+// newRegistryBalancer builds a registryBalancer over addrs (trimmed and
+// deduplicated, so "a:1, b:2" and "a:1,b:2" behave the same), sharing
+// clientSet's connection pool.
+func newRegistryBalancer(addrs []string, clientSet *RegistryClientSet) *registryBalancer {
+	b := &registryBalancer{
+		clientSet:     clientSet,
+		cooldown:      defaultUnhealthyCooldown,
+		probeInterval: defaultProbeInterval,
+	}
+
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		if addr == "" || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		b.endpoints = append(b.endpoints, &registryBalancerEndpoint{addr: addr})
+	}
+	return b
+}
+
+// pick returns the endpoint Register/Renew/Unregister should use: the
+// currently pinned endpoint if it's still healthy, otherwise the next
+// healthy endpoint in configured order. endpointChanged reports whether
+// the pinned endpoint differs from the one in effect before this call, so
+// callers holding onto a lease acquired against the old endpoint know to
+// re-acquire it rather than renew it against the new one.
+func (b *registryBalancer) pick() (addr string, endpointChanged bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := timeNow()
+	if b.active != "" {
+		for _, ep := range b.endpoints {
+			if ep.addr == b.active && now.After(ep.unhealthyUntil) {
+				return b.active, false, nil
+			}
+		}
+	}
+
+	for _, ep := range b.endpoints {
+		if now.After(ep.unhealthyUntil) {
+			changed := b.active != ep.addr
+			b.active = ep.addr
+			return ep.addr, changed, nil
+		}
+	}
+
+	return "", false, fmt.Errorf("no healthy registry endpoint available out of %d configured", len(b.endpoints))
+}
+
+// registryClient resolves pick()'s chosen endpoint to a RegistryServiceClient
+// bound to its pooled connection.
+func (b *registryBalancer) registryClient() (client RegistryServiceClient, addr string, endpointChanged bool, err error) {
+	addr, endpointChanged, err = b.pick()
+	if err != nil {
+		return nil, "", false, err
+	}
+	client, err = b.clientSet.RegistryClient(addr)
+	return client, addr, endpointChanged, err
+}
+
+// markUnhealthy benches addr for b.cooldown, unless err indicates the
+// caller gave up rather than the registry failing (context cancellation),
+// and un-pins addr so the next pick() fails over to a different endpoint.
+func (b *registryBalancer) markUnhealthy(addr string, err error) {
+	if err == nil || status.Code(err) == codes.Canceled || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ep := range b.endpoints {
+		if ep.addr == addr {
+			ep.unhealthyUntil = timeNow().Add(b.cooldown)
+			break
+		}
+	}
+	if b.active == addr {
+		b.active = ""
+	}
+}
+
+// runProbeLoop re-probes benched endpoints every b.probeInterval until stop
+// is closed, reintegrating any that respond SERVING.
+func (b *registryBalancer) runProbeLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(b.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			b.probeUnhealthy()
+		}
+	}
+}
+
+func (b *registryBalancer) probeUnhealthy() {
+	b.mu.Lock()
+	now := timeNow()
+	var benched []string
+	for _, ep := range b.endpoints {
+		if now.Before(ep.unhealthyUntil) {
+			benched = append(benched, ep.addr)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, addr := range benched {
+		healthClient, err := b.clientSet.HealthClient(addr)
+		if err != nil {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		resp, err := healthClient.Check(ctx, &healthpb.HealthCheckRequest{})
+		cancel()
+		if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+			continue
+		}
+
+		b.mu.Lock()
+		for _, ep := range b.endpoints {
+			if ep.addr == addr {
+				ep.unhealthyUntil = time.Time{}
+			}
+		}
+		b.mu.Unlock()
+		log.Printf("registry endpoint %s healthy again, reintegrating", addr)
+	}
+}
@@ -0,0 +1,336 @@
+package parallax
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy configures how the SDK retries transient control-plane RPC
+// failures and when it trips a circuit breaker around a persistently
+// unhealthy registry, so a network blip or rolling restart doesn't
+// surface as a hard failure to Register/Renew/Heartbeat callers. A nil
+// *RetryPolicy behaves like a single-attempt, breaker-free policy: every
+// method here is nil-safe.
+type RetryPolicy struct {
+	// MaxAttempts caps attempts per call, including the first. Zero (or a
+	// nil RetryPolicy) means one attempt, i.e. no retries.
+	MaxAttempts int
+
+	// InitialBackoff and MaxBackoff bound the exponential backoff between
+	// attempts; each delay is also jittered by +/-Jitter of itself.
+	// Zero values fall back to 200ms and 5s respectively.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Jitter         float64
+
+	// RetryableCodes lists the gRPC codes worth retrying, e.g.
+	// Unavailable and DeadlineExceeded. Codes that indicate the request
+	// itself was bad (InvalidArgument, NotFound, PermissionDenied, ...)
+	// are never retried regardless of this list.
+	RetryableCodes []codes.Code
+
+	// Breaker configures the circuit breaker layered on top of retries.
+	// Nil disables the breaker; retries still apply.
+	Breaker *CircuitBreakerConfig
+}
+
+// CircuitBreakerConfig tunes a closed/open/half-open circuit breaker over
+// a rolling window of recent call outcomes.
+type CircuitBreakerConfig struct {
+	// Window is how many recent outcomes are tracked.
+	Window int
+
+	// FailureRate in [0,1] trips the breaker once Window outcomes have
+	// accumulated and at least this fraction of them failed.
+	FailureRate float64
+
+	// OpenDuration is how long the breaker stays open before allowing a
+	// single half-open probe through.
+	OpenDuration time.Duration
+}
+
+// neverRetryCodes are gRPC codes that mean the request itself was bad, so
+// retrying it would just fail the same way again.
+var neverRetryCodes = map[codes.Code]bool{
+	codes.InvalidArgument:    true,
+	codes.NotFound:           true,
+	codes.AlreadyExists:      true,
+	codes.PermissionDenied:   true,
+	codes.Unauthenticated:    true,
+	codes.FailedPrecondition: true,
+}
+
+// DefaultRetryPolicy makes up to 5 attempts (the first plus 4 retries) on
+// Unavailable/DeadlineExceeded with exponential backoff from 200ms to 5s, breaker-protected with a 50%
+// failure rate tripping over a 20-call window and a 10s open period. It is
+// the policy NewClient and NewParallaxAgent fall back to when none is
+// configured.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Jitter:         0.2,
+		RetryableCodes: []codes.Code{codes.Unavailable, codes.DeadlineExceeded},
+		Breaker: &CircuitBreakerConfig{
+			Window:       20,
+			FailureRate:  0.5,
+			OpenDuration: 10 * time.Second,
+		},
+	}
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts <= 0 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// retryable reports whether err is worth a further attempt under p.
+func (p *RetryPolicy) retryable(err error) bool {
+	if p == nil || err == nil {
+		return false
+	}
+
+	code := status.Code(err)
+	if neverRetryCodes[code] {
+		return false
+	}
+	for _, c := range p.RetryableCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the jittered delay before the given zero-based retry
+// attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = 200 * time.Millisecond
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = 5 * time.Second
+	}
+
+	delay := initial * time.Duration(1<<uint(attempt))
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+
+	if p.Jitter > 0 {
+		delta := float64(delay) * p.Jitter
+		delay += time.Duration(rand.Float64()*2*delta - delta)
+		if delay < 0 {
+			delay = 0
+		}
+	}
+	return delay
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor that
+// retries retryable failures per p and sheds load through a circuit
+// breaker once the registry looks persistently unhealthy. Pass it via
+// ClientSetOptions.UnaryInterceptors; NewClient and NewParallaxAgent wire
+// this in automatically using RetryPolicy/DefaultRetryPolicy.
+func (p *RetryPolicy) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	breaker := newCircuitBreaker(p.breakerConfig())
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var err error
+		for attempt := 0; attempt < p.maxAttempts(); attempt++ {
+			if !breaker.allow() {
+				return status.Errorf(codes.Unavailable, "circuit breaker open for %s", method)
+			}
+
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			breaker.record(err == nil)
+			if err == nil || !p.retryable(err) {
+				return err
+			}
+			if attempt == p.maxAttempts()-1 {
+				break
+			}
+			if !sleepOrDone(ctx, p.backoff(attempt)) {
+				return ctx.Err()
+			}
+		}
+		return err
+	}
+}
+
+// StreamClientInterceptor retries stream establishment the same way
+// UnaryClientInterceptor retries unary calls. Once a stream is open,
+// message-level errors are left to the caller: retrying mid-stream would
+// silently replay messages the caller already believes it sent.
+func (p *RetryPolicy) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	breaker := newCircuitBreaker(p.breakerConfig())
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		var stream grpc.ClientStream
+		var err error
+		for attempt := 0; attempt < p.maxAttempts(); attempt++ {
+			if !breaker.allow() {
+				return nil, status.Errorf(codes.Unavailable, "circuit breaker open for %s", method)
+			}
+
+			stream, err = streamer(ctx, desc, cc, method, opts...)
+			breaker.record(err == nil)
+			if err == nil || !p.retryable(err) {
+				return stream, err
+			}
+			if attempt == p.maxAttempts()-1 {
+				break
+			}
+			if !sleepOrDone(ctx, p.backoff(attempt)) {
+				return nil, ctx.Err()
+			}
+		}
+		return stream, err
+	}
+}
+
+func (p *RetryPolicy) breakerConfig() *CircuitBreakerConfig {
+	if p == nil {
+		return nil
+	}
+	return p.Breaker
+}
+
+// sleepOrDone waits for d or ctx cancellation, reporting false if ctx won.
+func sleepOrDone(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// circuitState is the state of a circuitBreaker.
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker tracks a rolling window of RPC outcomes and trips open
+// once the failure rate within that window crosses its configured
+// threshold, cycling closed -> open -> half-open -> closed (or back to
+// open on a failed probe). A nil *circuitBreaker always allows calls
+// through, matching a RetryPolicy with no Breaker configured.
+type circuitBreaker struct {
+	cfg CircuitBreakerConfig
+
+	mu        sync.Mutex
+	state     circuitState
+	outcomes  []bool // true = success
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(cfg *CircuitBreakerConfig) *circuitBreaker {
+	if cfg == nil {
+		return nil
+	}
+	return &circuitBreaker{cfg: *cfg}
+}
+
+// allow reports whether a call may proceed, transitioning open -> half-open
+// once OpenDuration has elapsed and admitting exactly one probe while
+// half-open.
+func (b *circuitBreaker) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if timeNow().Before(b.openUntil) {
+			return false
+		}
+		b.state = circuitHalfOpen
+		b.probing = true
+		return true
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record logs the outcome of a call allow let through, updating the
+// rolling window and tripping or resetting the breaker as needed.
+func (b *circuitBreaker) record(success bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		b.probing = false
+		if success {
+			b.state = circuitClosed
+			b.outcomes = nil
+		} else {
+			b.trip()
+		}
+		return
+	}
+
+	window := b.cfg.Window
+	if window <= 0 {
+		window = 20
+	}
+	b.outcomes = append(b.outcomes, success)
+	if len(b.outcomes) > window {
+		b.outcomes = b.outcomes[len(b.outcomes)-window:]
+	}
+	if len(b.outcomes) < window {
+		return
+	}
+
+	failures := 0
+	for _, ok := range b.outcomes {
+		if !ok {
+			failures++
+		}
+	}
+	if float64(failures)/float64(len(b.outcomes)) >= b.cfg.FailureRate {
+		b.trip()
+	}
+}
+
+// trip opens the breaker. Callers must hold b.mu.
+func (b *circuitBreaker) trip() {
+	b.state = circuitOpen
+	b.probing = false
+	b.outcomes = nil
+
+	openDuration := b.cfg.OpenDuration
+	if openDuration <= 0 {
+		openDuration = 10 * time.Second
+	}
+	b.openUntil = timeNow().Add(openDuration)
+}
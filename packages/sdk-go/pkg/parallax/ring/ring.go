@@ -0,0 +1,139 @@
+// Package ring places agents on a consistent hash ring keyed by
+// capability, so the control plane can pick a stable subset of eligible
+// agents for a pattern execution instead of an arbitrary one — repeated
+// lookups for the same capability land on (mostly) the same agents even
+// as the fleet scales, which keeps warm caches and in-flight state useful
+// across executions.
+package ring
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const virtualNodesPerCapability = 100
+
+// Ring is a capability-keyed consistent hash ring. The zero value is not
+// usable; build one with New. Safe for concurrent use.
+type Ring struct {
+	mu sync.RWMutex
+
+	// vnodes maps capability -> vnode hash -> agent ID.
+	vnodes map[string]map[uint32]string
+
+	// sortedHashes caches vnodes' keys sorted ascending, per capability,
+	// invalidated whenever that capability's membership changes.
+	sortedHashes map[string][]uint32
+
+	// agents maps agent ID -> the capabilities it was added under, so
+	// RemoveAgent can clean up every ring it placed that agent on.
+	agents map[string][]string
+}
+
+// New returns an empty Ring.
+func New() *Ring {
+	return &Ring{
+		vnodes:       make(map[string]map[uint32]string),
+		sortedHashes: make(map[string][]uint32),
+		agents:       make(map[string][]string),
+	}
+}
+
+// AddAgent places agentID on the ring for each of capabilities. Calling it
+// again for the same agentID first removes its prior placement, so it's
+// safe to call on every heartbeat/capability change.
+func (r *Ring) AddAgent(agentID string, capabilities []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeAgentLocked(agentID)
+	r.agents[agentID] = capabilities
+
+	for _, capability := range capabilities {
+		ring, ok := r.vnodes[capability]
+		if !ok {
+			ring = make(map[uint32]string)
+			r.vnodes[capability] = ring
+		}
+		for i := 0; i < virtualNodesPerCapability; i++ {
+			ring[vnodeHash(capability, agentID, i)] = agentID
+		}
+		delete(r.sortedHashes, capability)
+	}
+}
+
+// RemoveAgent removes agentID from every capability ring it was placed on.
+func (r *Ring) RemoveAgent(agentID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeAgentLocked(agentID)
+}
+
+func (r *Ring) removeAgentLocked(agentID string) {
+	capabilities, ok := r.agents[agentID]
+	if !ok {
+		return
+	}
+	delete(r.agents, agentID)
+
+	for _, capability := range capabilities {
+		ring, ok := r.vnodes[capability]
+		if !ok {
+			continue
+		}
+		for i := 0; i < virtualNodesPerCapability; i++ {
+			delete(ring, vnodeHash(capability, agentID, i))
+		}
+		if len(ring) == 0 {
+			delete(r.vnodes, capability)
+		}
+		delete(r.sortedHashes, capability)
+	}
+}
+
+// Get returns up to replicas distinct agent IDs for capability, walking
+// the ring clockwise from hash(capability) the way Dynamo-style rings
+// pick replica owners. It returns fewer than replicas if fewer agents
+// advertise capability, and nil if none do.
+func (r *Ring) Get(capability string, replicas int) []string {
+	// Get fills the sortedHashes cache, so it takes the write lock.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ring := r.vnodes[capability]
+	if len(ring) == 0 {
+		return nil
+	}
+
+	hashes := r.sortedHashes[capability]
+	if hashes == nil {
+		hashes = make([]uint32, 0, len(ring))
+		for h := range ring {
+			hashes = append(hashes, h)
+		}
+		sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+		r.sortedHashes[capability] = hashes
+	}
+
+	start := sort.Search(len(hashes), func(i int) bool {
+		return hashes[i] >= crc32.ChecksumIEEE([]byte(capability))
+	})
+
+	seen := make(map[string]struct{}, replicas)
+	owners := make([]string, 0, replicas)
+	for i := 0; i < len(hashes) && len(owners) < replicas; i++ {
+		agentID := ring[hashes[(start+i)%len(hashes)]]
+		if _, ok := seen[agentID]; ok {
+			continue
+		}
+		seen[agentID] = struct{}{}
+		owners = append(owners, agentID)
+	}
+	return owners
+}
+
+func vnodeHash(capability, agentID string, index int) uint32 {
+	return crc32.ChecksumIEEE([]byte(capability + "/" + agentID + "#" + strconv.Itoa(index)))
+}
@@ -0,0 +1,637 @@
+package parallax
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AgentScorer scores a single candidate agent for a pattern execution;
+// higher is better. SelectRequest.Scorer lets callers plug in their own
+// (e.g. weighting by recent latency or task-specific history) instead of
+// the default confidence-based scorer the "best_fit" Selector falls back
+// to.
+type AgentScorer interface {
+	Score(ctx context.Context, agent *AgentInfo) float64
+}
+
+// AgentScorerFunc adapts a plain function to AgentScorer.
+type AgentScorerFunc func(ctx context.Context, agent *AgentInfo) float64
+
+// Score implements AgentScorer.
+func (f AgentScorerFunc) Score(ctx context.Context, agent *AgentInfo) float64 { return f(ctx, agent) }
+
+// confidenceScorer is the default AgentScorer: an agent's own
+// last-reported Confidence.
+var confidenceScorer AgentScorer = AgentScorerFunc(func(_ context.Context, agent *AgentInfo) float64 {
+	return agent.Confidence
+})
+
+// SelectRequest describes one agent-selection decision: the pattern and
+// input being executed, the capabilities patternService.eligibleAgents
+// already filtered candidates down to, how many agents to return, and
+// any Selector-specific Config. patternService.eligibleAgents builds one
+// per Execute call and hands it to the Selector named by
+// AgentSelector.Strategy.
+type SelectRequest struct {
+	// Pattern is the pattern name being executed, e.g. "consensus-builder".
+	Pattern string
+
+	// Input is the value Execute was called with, unchanged. Selectors
+	// that want cache affinity (e.g. consistent-hash-by-input) hash this
+	// instead of a capability name.
+	Input interface{}
+
+	// Capabilities are the capabilities every candidate already carries
+	// (patternService.eligibleAgents has already filtered on them); a
+	// Selector that cares about covering distinct capabilities (e.g.
+	// capability-cover-set) reads this to know which ones to cover.
+	Capabilities []string
+
+	// MaxCount is the number of agents to return. A Selector should
+	// return every candidate unchanged if MaxCount is <= 0 or exceeds the
+	// candidate count.
+	MaxCount int
+
+	// Config carries Selector-specific parameters for a Selector that
+	// reads them at Select time. The built-in selectors don't read it:
+	// the stats-backed ones get their SelectorStats from the factory cfg
+	// at resolution time, and eligibleAgents leaves Config unset.
+	Config map[string]interface{}
+
+	// Scorer overrides the AgentScorer the "best_fit" Selector (and any
+	// custom Selector that chooses to honor it) ranks candidates with.
+	// Nil falls back to scoring by AgentInfo.Confidence.
+	Scorer AgentScorer
+}
+
+// Selector narrows candidates down to at most req.MaxCount agents for
+// one pattern execution. RegisterSelector lets callers add or replace
+// selectors by name, so an AgentSelector.Strategy is a free-form name
+// resolved through the registry rather than one of a fixed set of
+// constants.
+type Selector interface {
+	Select(ctx context.Context, candidates []*AgentInfo, req SelectRequest) ([]*AgentInfo, error)
+}
+
+// SelectorFunc adapts a plain function to Selector.
+type SelectorFunc func(ctx context.Context, candidates []*AgentInfo, req SelectRequest) ([]*AgentInfo, error)
+
+// Select implements Selector.
+func (f SelectorFunc) Select(ctx context.Context, candidates []*AgentInfo, req SelectRequest) ([]*AgentInfo, error) {
+	return f(ctx, candidates, req)
+}
+
+// ScoredSelector is implemented by Selectors whose choice is driven by a
+// per-candidate numeric score computed from something other than the
+// candidate's raw AgentInfo.Confidence — e.g. power-of-two-choices'
+// confidence/latency blend, or expertise-weighted's per-pattern rolling
+// confidence. patternService.eligibleAgents calls Score on the chosen
+// agents to populate PatternExecution.Metadata's "selector_scores" with
+// the value the Selector actually used, instead of silently substituting
+// AgentInfo.Confidence for Selectors that scored on something else.
+type ScoredSelector interface {
+	Selector
+	Score(ctx context.Context, agent *AgentInfo, req SelectRequest) float64
+}
+
+// SelectorFactory builds a Selector from cfg, the Config a
+// RegisterSelector caller supplies at resolution time (see
+// resolveSelector). Most built-in factories ignore cfg entirely; the
+// stats-backed ones read a *SelectorStats out of it.
+type SelectorFactory func(cfg map[string]interface{}) (Selector, error)
+
+var (
+	selectorsMu       sync.RWMutex
+	selectorFactories = map[string]SelectorFactory{}
+	selectorCache     = map[string]Selector{}
+)
+
+func init() {
+	RegisterSelector(string(SelectionStrategyRandom), func(map[string]interface{}) (Selector, error) {
+		return SelectorFunc(selectRandom), nil
+	})
+	RegisterSelector(string(SelectionStrategyRoundRobin), func(map[string]interface{}) (Selector, error) {
+		return &roundRobinSelector{}, nil
+	})
+	RegisterSelector(string(SelectionStrategyBestFit), func(map[string]interface{}) (Selector, error) {
+		return &bestFitSelector{}, nil
+	})
+	RegisterSelector(string(SelectionStrategyAll), func(map[string]interface{}) (Selector, error) {
+		return SelectorFunc(selectAll), nil
+	})
+	RegisterSelector(selectorConsistentHashByInput, func(map[string]interface{}) (Selector, error) {
+		return SelectorFunc(selectConsistentHashByInput), nil
+	})
+	RegisterSelector(selectorPowerOfTwoChoices, func(cfg map[string]interface{}) (Selector, error) {
+		return &powerOfTwoChoicesSelector{stats: statsFromConfig(cfg)}, nil
+	})
+	RegisterSelector(selectorCapabilityCoverSet, func(map[string]interface{}) (Selector, error) {
+		return SelectorFunc(selectCapabilityCoverSet), nil
+	})
+	RegisterSelector(selectorExpertiseWeighted, func(cfg map[string]interface{}) (Selector, error) {
+		return &expertiseWeightedSelector{stats: statsFromConfig(cfg)}, nil
+	})
+}
+
+// RegisterSelector installs factory under name, making it available to
+// any AgentSelector.Strategy naming it. It overrides any existing
+// factory registered under the same name, including every built-in
+// selector (random/round_robin/best_fit/all/consistent-hash-by-input/
+// power-of-two-choices/capability-cover-set/expertise-weighted), so
+// third parties can add or replace selection behavior without forking
+// the SDK.
+func RegisterSelector(name string, factory SelectorFactory) {
+	selectorsMu.Lock()
+	defer selectorsMu.Unlock()
+	selectorFactories[name] = factory
+	delete(selectorCache, name)
+}
+
+// resolveSelector returns the Selector registered under name. A call
+// with an empty cfg is served from selectorCache (so a stateful Selector
+// like roundRobinSelector keeps its cursor across calls instead of being
+// rebuilt every time); a non-empty cfg always builds a fresh instance,
+// since cfg is how per-client state like a *SelectorStats gets threaded
+// in without leaking into the shared cache. An empty or unregistered
+// name falls back to "best_fit", the same degrade the old
+// selectionStrategyFor used.
+func resolveSelector(name string, cfg map[string]interface{}) (Selector, error) {
+	if len(cfg) == 0 {
+		selectorsMu.RLock()
+		cached, ok := selectorCache[name]
+		selectorsMu.RUnlock()
+		if ok {
+			return cached, nil
+		}
+	}
+
+	selectorsMu.RLock()
+	factory, ok := selectorFactories[name]
+	selectorsMu.RUnlock()
+	if !ok {
+		selectorsMu.RLock()
+		factory, ok = selectorFactories[string(SelectionStrategyBestFit)]
+		selectorsMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("parallax: no selector registered for %q", name)
+		}
+	}
+
+	selector, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("parallax: build selector %q: %w", name, err)
+	}
+
+	if len(cfg) == 0 {
+		selectorsMu.Lock()
+		selectorCache[name] = selector
+		selectorsMu.Unlock()
+	}
+	return selector, nil
+}
+
+// statsFromConfig returns the *SelectorStats in a factory's cfg (by the
+// well-known "stats" key), or nil if none was supplied. The stats-backed
+// built-in factories use this; a patternService passes its own stats in
+// via resolveSelectorForPattern.
+func statsFromConfig(cfg map[string]interface{}) *SelectorStats {
+	stats, _ := cfg["stats"].(*SelectorStats)
+	return stats
+}
+
+// selectRandom returns a random subset of req.MaxCount candidates.
+func selectRandom(_ context.Context, candidates []*AgentInfo, req SelectRequest) ([]*AgentInfo, error) {
+	if req.MaxCount <= 0 || req.MaxCount >= len(candidates) {
+		return candidates, nil
+	}
+	shuffled := append([]*AgentInfo(nil), candidates...)
+	rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled[:req.MaxCount], nil
+}
+
+// bestFitSelector returns the req.MaxCount highest-scoring candidates
+// under req.Scorer, defaulting to confidenceScorer when it's nil. It
+// implements ScoredSelector so eligibleAgents can report the Scorer's
+// actual score rather than assuming it's always AgentInfo.Confidence.
+type bestFitSelector struct{}
+
+// Select implements Selector.
+func (b bestFitSelector) Select(ctx context.Context, candidates []*AgentInfo, req SelectRequest) ([]*AgentInfo, error) {
+	if req.MaxCount <= 0 || req.MaxCount >= len(candidates) {
+		return candidates, nil
+	}
+
+	type scored struct {
+		agent *AgentInfo
+		score float64
+	}
+	ranked := make([]scored, len(candidates))
+	for i, agent := range candidates {
+		ranked[i] = scored{agent: agent, score: b.Score(ctx, agent, req)}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	subset := make([]*AgentInfo, req.MaxCount)
+	for i := range subset {
+		subset[i] = ranked[i].agent
+	}
+	return subset, nil
+}
+
+// Score implements ScoredSelector, applying req.Scorer (or
+// confidenceScorer, if nil) to agent.
+func (b bestFitSelector) Score(ctx context.Context, agent *AgentInfo, req SelectRequest) float64 {
+	scorer := req.Scorer
+	if scorer == nil {
+		scorer = confidenceScorer
+	}
+	return scorer.Score(ctx, agent)
+}
+
+// selectAll ignores req.MaxCount and returns every candidate, for
+// callers that set Strategy to SelectionStrategyAll specifically to opt
+// out of MaxCount truncation while still recording their intent in the
+// selector.
+func selectAll(_ context.Context, candidates []*AgentInfo, _ SelectRequest) ([]*AgentInfo, error) {
+	return candidates, nil
+}
+
+// roundRobinSelector returns req.MaxCount candidates starting from a
+// cursor that advances by MaxCount on every call, wrapping around the
+// candidate list, so repeated executions of the same pattern spread
+// load across the eligible fleet instead of always picking the same
+// prefix.
+type roundRobinSelector struct {
+	cursor uint64
+}
+
+// Select implements Selector.
+func (r *roundRobinSelector) Select(_ context.Context, candidates []*AgentInfo, req SelectRequest) ([]*AgentInfo, error) {
+	if req.MaxCount <= 0 || req.MaxCount >= len(candidates) {
+		return candidates, nil
+	}
+
+	n := len(candidates)
+	start := int(atomic.AddUint64(&r.cursor, uint64(req.MaxCount))-uint64(req.MaxCount)) % n
+
+	subset := make([]*AgentInfo, req.MaxCount)
+	for i := range subset {
+		subset[i] = candidates[(start+i)%n]
+	}
+	return subset, nil
+}
+
+const selectorConsistentHashByInput = "consistent-hash-by-input"
+
+// selectConsistentHashByInput narrows candidates to req.MaxCount agents
+// by walking a hash ring built from the candidates' IDs, starting from
+// hash(req.Input). Unlike patternService.stableSubset's ring (keyed by
+// required capability, for spreading load across the whole eligible
+// fleet), this hashes on the execution's own Input, so repeated
+// executions of the same input land on the same small set of agents —
+// useful for cache affinity when agents keep per-input state (e.g. a
+// warmed model context) between calls.
+func selectConsistentHashByInput(_ context.Context, candidates []*AgentInfo, req SelectRequest) ([]*AgentInfo, error) {
+	if req.MaxCount <= 0 || req.MaxCount >= len(candidates) {
+		return candidates, nil
+	}
+
+	type node struct {
+		agent *AgentInfo
+		hash  uint32
+	}
+	ring := make([]node, len(candidates))
+	for i, agent := range candidates {
+		ring[i] = node{agent: agent, hash: crc32.ChecksumIEEE([]byte(agent.ID))}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+
+	key := crc32.ChecksumIEEE([]byte(inputCacheKey(req.Input)))
+	start := sort.Search(len(ring), func(i int) bool { return ring[i].hash >= key })
+
+	subset := make([]*AgentInfo, req.MaxCount)
+	for i := range subset {
+		subset[i] = ring[(start+i)%len(ring)].agent
+	}
+	return subset, nil
+}
+
+// inputCacheKey renders input into a stable string for hashing, falling
+// back to fmt.Sprintf when it isn't JSON-marshalable (e.g. it contains a
+// channel or function value).
+func inputCacheKey(input interface{}) string {
+	if data, err := json.Marshal(input); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", input)
+}
+
+const selectorCapabilityCoverSet = "capability-cover-set"
+
+// selectCapabilityCoverSet greedily picks the smallest subset of
+// candidates whose union of Capabilities covers req.Capabilities,
+// repeatedly choosing whichever remaining candidate covers the most
+// still-uncovered capability until every one is covered or req.MaxCount
+// is reached (if set). Useful when a pattern needs several distinct
+// capabilities and no single agent offers all of them.
+func selectCapabilityCoverSet(_ context.Context, candidates []*AgentInfo, req SelectRequest) ([]*AgentInfo, error) {
+	needed := make(map[string]struct{}, len(req.Capabilities))
+	for _, c := range req.Capabilities {
+		needed[c] = struct{}{}
+	}
+	if len(needed) == 0 {
+		if req.MaxCount > 0 && req.MaxCount < len(candidates) {
+			return candidates[:req.MaxCount], nil
+		}
+		return candidates, nil
+	}
+
+	remaining := append([]*AgentInfo(nil), candidates...)
+	var subset []*AgentInfo
+	for len(needed) > 0 && len(remaining) > 0 {
+		bestIdx, bestNew := -1, 0
+		for i, agent := range remaining {
+			covers := 0
+			for _, c := range agent.Capabilities {
+				if _, ok := needed[c]; ok {
+					covers++
+				}
+			}
+			if covers > bestNew {
+				bestIdx, bestNew = i, covers
+			}
+		}
+		if bestIdx == -1 {
+			break
+		}
+
+		chosen := remaining[bestIdx]
+		subset = append(subset, chosen)
+		for _, c := range chosen.Capabilities {
+			delete(needed, c)
+		}
+		remaining = append(remaining[:bestIdx], remaining[bestIdx+1:]...)
+
+		if req.MaxCount > 0 && len(subset) == req.MaxCount {
+			break
+		}
+	}
+	return subset, nil
+}
+
+const selectorPowerOfTwoChoices = "power-of-two-choices"
+
+// powerOfTwoChoicesSelector fills req.MaxCount slots by repeatedly
+// sampling two random remaining candidates and keeping the one that
+// scores higher, the "power of two choices" load-balancing strategy: it
+// spreads load almost as evenly as always picking the single best
+// candidate, without that approach's tendency to pile every request onto
+// whichever agent currently looks best.
+type powerOfTwoChoicesSelector struct {
+	stats *SelectorStats
+}
+
+// Select implements Selector.
+func (p *powerOfTwoChoicesSelector) Select(_ context.Context, candidates []*AgentInfo, req SelectRequest) ([]*AgentInfo, error) {
+	if req.MaxCount <= 0 || req.MaxCount >= len(candidates) {
+		return candidates, nil
+	}
+
+	remaining := append([]*AgentInfo(nil), candidates...)
+	subset := make([]*AgentInfo, 0, req.MaxCount)
+	for len(subset) < req.MaxCount {
+		i := rand.Intn(len(remaining))
+		j := rand.Intn(len(remaining))
+
+		chosen := i
+		if p.score(remaining[j]) > p.score(remaining[i]) {
+			chosen = j
+		}
+
+		subset = append(subset, remaining[chosen])
+		remaining = append(remaining[:chosen], remaining[chosen+1:]...)
+	}
+	return subset, nil
+}
+
+// score combines an agent's rolling confidence and latency (from
+// p.stats, if it's observed any executions for agent yet) into a single
+// value: higher confidence and lower latency both score better. Falls
+// back to the agent's own last-reported Confidence with no latency
+// penalty when p.stats has no samples yet.
+func (p *powerOfTwoChoicesSelector) score(agent *AgentInfo) float64 {
+	confidence := agent.Confidence
+	var latency time.Duration
+	if p.stats != nil {
+		if c, ok := p.stats.Confidence(agent.ID); ok {
+			confidence = c
+		}
+		latency, _ = p.stats.Latency(agent.ID)
+	}
+	return confidence / (1 + latency.Seconds())
+}
+
+// Score implements ScoredSelector, exposing the same confidence/latency
+// blend Select compares candidates with.
+func (p *powerOfTwoChoicesSelector) Score(_ context.Context, agent *AgentInfo, _ SelectRequest) float64 {
+	return p.score(agent)
+}
+
+const selectorExpertiseWeighted = "expertise-weighted"
+
+// minExpertiseWeight keeps every candidate sampleable by
+// expertiseWeightedSelector even with no confidence history for it yet,
+// just unlikely relative to a candidate with a strong track record.
+const minExpertiseWeight = 0.01
+
+// expertiseWeightedSelector fills req.MaxCount slots by weighted random
+// sampling without replacement, where each candidate's weight is its
+// historical confidence on req.Pattern specifically (from e.stats),
+// rather than its overall Confidence — an agent that's historically
+// strong on this pattern is proportionally more likely to be picked,
+// without bestFitSelector's "always the current top scorer" determinism.
+type expertiseWeightedSelector struct {
+	stats *SelectorStats
+}
+
+// Select implements Selector.
+func (e *expertiseWeightedSelector) Select(_ context.Context, candidates []*AgentInfo, req SelectRequest) ([]*AgentInfo, error) {
+	if req.MaxCount <= 0 || req.MaxCount >= len(candidates) {
+		return candidates, nil
+	}
+
+	remaining := append([]*AgentInfo(nil), candidates...)
+	subset := make([]*AgentInfo, 0, req.MaxCount)
+	for len(subset) < req.MaxCount {
+		weights := make([]float64, len(remaining))
+		var total float64
+		for i, agent := range remaining {
+			w := e.weight(agent, req.Pattern)
+			weights[i] = w
+			total += w
+		}
+
+		pick := rand.Float64() * total
+		idx := len(remaining) - 1
+		var cum float64
+		for i, w := range weights {
+			cum += w
+			if pick <= cum {
+				idx = i
+				break
+			}
+		}
+
+		subset = append(subset, remaining[idx])
+		remaining = append(remaining[:idx], remaining[idx+1:]...)
+	}
+	return subset, nil
+}
+
+// weight returns agent's sampling weight for pattern: its rolling
+// PatternConfidence from e.stats if any samples exist for that pairing,
+// else its raw AgentInfo.Confidence, floored at minExpertiseWeight so
+// every candidate stays sampleable.
+func (e *expertiseWeightedSelector) weight(agent *AgentInfo, pattern string) float64 {
+	w := agent.Confidence
+	if e.stats != nil {
+		if c, ok := e.stats.PatternConfidence(agent.ID, pattern); ok {
+			w = c
+		}
+	}
+	if w < minExpertiseWeight {
+		w = minExpertiseWeight
+	}
+	return w
+}
+
+// Score implements ScoredSelector, exposing the same per-pattern weight
+// Select samples candidates with.
+func (e *expertiseWeightedSelector) Score(_ context.Context, agent *AgentInfo, req SelectRequest) float64 {
+	return e.weight(agent, req.Pattern)
+}
+
+// statDecay weights each new sample's influence on a rollingStat's
+// exponential moving average; closer to 1 adapts to recent behavior
+// faster, closer to 0 smooths out noise from any single execution.
+const statDecay = 0.2
+
+// rollingStat is an exponential moving average over latency and
+// confidence, updated once per completed execution an agent (or
+// agent/pattern pairing) participated in.
+type rollingStat struct {
+	latency    time.Duration
+	confidence float64
+}
+
+func (s *rollingStat) observe(execution *PatternExecution) {
+	s.latency += time.Duration(statDecay * float64(execution.Duration-s.latency))
+	s.confidence += statDecay * (execution.Confidence - s.confidence)
+}
+
+// SelectorStats tracks rolling per-agent latency and confidence, and
+// separately per-agent-per-pattern confidence, fed by
+// PatternService.StreamExecutions (see watch). powerOfTwoChoicesSelector
+// and expertiseWeightedSelector score candidates from it instead of the
+// single static AgentInfo.Confidence snapshot List returns. The zero
+// value is not usable; build one with newSelectorStats.
+type SelectorStats struct {
+	mu sync.Mutex
+
+	byAgent        map[string]*rollingStat
+	byAgentPattern map[string]*rollingStat
+}
+
+func newSelectorStats() *SelectorStats {
+	return &SelectorStats{
+		byAgent:        make(map[string]*rollingStat),
+		byAgentPattern: make(map[string]*rollingStat),
+	}
+}
+
+// Record folds execution into every participating agent's rolling
+// stats, both overall (byAgent) and scoped to execution.Pattern
+// (byAgentPattern). Executions that haven't reached a terminal status
+// yet (EndTime unset, so Duration isn't final) are ignored.
+func (s *SelectorStats) Record(execution *PatternExecution) {
+	if execution == nil || execution.EndTime == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, agentID := range execution.Agents {
+		s.observe(s.byAgent, agentID, execution)
+		s.observe(s.byAgentPattern, agentID+"\x00"+execution.Pattern, execution)
+	}
+}
+
+func (s *SelectorStats) observe(stats map[string]*rollingStat, key string, execution *PatternExecution) {
+	stat, ok := stats[key]
+	if !ok {
+		stats[key] = &rollingStat{latency: execution.Duration, confidence: execution.Confidence}
+		return
+	}
+	stat.observe(execution)
+}
+
+// Latency returns agentID's rolling average execution latency, and
+// whether any samples have been recorded for it yet.
+func (s *SelectorStats) Latency(agentID string) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stat, ok := s.byAgent[agentID]
+	if !ok {
+		return 0, false
+	}
+	return stat.latency, true
+}
+
+// Confidence returns agentID's rolling average confidence across every
+// pattern it's executed, and whether any samples have been recorded for
+// it yet.
+func (s *SelectorStats) Confidence(agentID string) (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stat, ok := s.byAgent[agentID]
+	if !ok {
+		return 0, false
+	}
+	return stat.confidence, true
+}
+
+// PatternConfidence returns agentID's rolling average confidence
+// specifically on pattern, and whether any samples have been recorded
+// for that pairing yet.
+func (s *SelectorStats) PatternConfidence(agentID, pattern string) (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stat, ok := s.byAgentPattern[agentID+"\x00"+pattern]
+	if !ok {
+		return 0, false
+	}
+	return stat.confidence, true
+}
+
+// watch subscribes to svc.StreamExecutions and records every update
+// until ctx is done or the stream closes. newPatternService runs this in
+// its own goroutine, so powerOfTwoChoicesSelector and
+// expertiseWeightedSelector have real rolling stats to score against
+// from the moment the first execution completes.
+func (s *SelectorStats) watch(ctx context.Context, svc PatternService) error {
+	ch, err := svc.StreamExecutions(ctx)
+	if err != nil {
+		return err
+	}
+	for execution := range ch {
+		s.Record(execution)
+	}
+	return nil
+}
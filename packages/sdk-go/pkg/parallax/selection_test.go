@@ -0,0 +1,200 @@
+package parallax
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRoundRobinSelectorAdvancesCursor(t *testing.T) {
+	candidates := []*AgentInfo{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	sel := &roundRobinSelector{}
+	req := SelectRequest{MaxCount: 1}
+
+	first, err := sel.Select(context.Background(), candidates, req)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	second, err := sel.Select(context.Background(), candidates, req)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if first[0].ID == second[0].ID {
+		t.Fatalf("consecutive Select calls both returned %q, want the cursor to advance", first[0].ID)
+	}
+}
+
+func TestSelectConsistentHashByInputIsStable(t *testing.T) {
+	candidates := []*AgentInfo{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	req := SelectRequest{MaxCount: 2, Input: "same-input"}
+
+	first, err := selectConsistentHashByInput(context.Background(), candidates, req)
+	if err != nil {
+		t.Fatalf("selectConsistentHashByInput: %v", err)
+	}
+	second, err := selectConsistentHashByInput(context.Background(), candidates, req)
+	if err != nil {
+		t.Fatalf("selectConsistentHashByInput: %v", err)
+	}
+
+	if len(first) != len(second) {
+		t.Fatalf("got %d and %d candidates, want matching lengths", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].ID != second[i].ID {
+			t.Fatalf("selectConsistentHashByInput(%q) not stable: %q != %q at index %d", req.Input, first[i].ID, second[i].ID, i)
+		}
+	}
+}
+
+func TestSelectCapabilityCoverSetGreedyCoverage(t *testing.T) {
+	candidates := []*AgentInfo{
+		{ID: "covers-ab", Capabilities: []string{"a", "b"}},
+		{ID: "covers-c", Capabilities: []string{"c"}},
+		{ID: "covers-a", Capabilities: []string{"a"}},
+	}
+	req := SelectRequest{Capabilities: []string{"a", "b", "c"}}
+
+	subset, err := selectCapabilityCoverSet(context.Background(), candidates, req)
+	if err != nil {
+		t.Fatalf("selectCapabilityCoverSet: %v", err)
+	}
+
+	covered := make(map[string]bool)
+	for _, agent := range subset {
+		for _, c := range agent.Capabilities {
+			covered[c] = true
+		}
+	}
+	for _, c := range req.Capabilities {
+		if !covered[c] {
+			t.Fatalf("selectCapabilityCoverSet didn't cover capability %q, result: %v", c, subset)
+		}
+	}
+	if len(subset) != 2 {
+		t.Fatalf("selectCapabilityCoverSet picked %d agents, want the greedy minimum of 2", len(subset))
+	}
+}
+
+func TestBestFitSelectorRespectsMaxCountAndScorer(t *testing.T) {
+	candidates := []*AgentInfo{{ID: "low", Confidence: 0.2}, {ID: "high", Confidence: 0.9}, {ID: "mid", Confidence: 0.5}}
+	sel := &bestFitSelector{}
+	req := SelectRequest{MaxCount: 1}
+
+	subset, err := sel.Select(context.Background(), candidates, req)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if len(subset) != 1 || subset[0].ID != "high" {
+		t.Fatalf("Select = %v, want the single highest-confidence agent %q", subset, "high")
+	}
+
+	if score := sel.Score(context.Background(), candidates[0], req); score != candidates[0].Confidence {
+		t.Fatalf("Score(%q) = %v, want %v (default confidenceScorer)", candidates[0].ID, score, candidates[0].Confidence)
+	}
+}
+
+func TestPowerOfTwoChoicesSelectorImplementsScoredSelector(t *testing.T) {
+	stats := newSelectorStats()
+	sel := &powerOfTwoChoicesSelector{stats: stats}
+	agent := &AgentInfo{ID: "a", Confidence: 0.5}
+	req := SelectRequest{}
+
+	before := sel.Score(context.Background(), agent, req)
+	if before != 0.5 {
+		t.Fatalf("Score with no recorded stats = %v, want the raw Confidence %v", before, agent.Confidence)
+	}
+
+	end := time.Now()
+	stats.Record(&PatternExecution{
+		Agents:     []string{"a"},
+		Duration:   time.Second,
+		Confidence: 0.9,
+		EndTime:    &end,
+	})
+
+	after := sel.Score(context.Background(), agent, req)
+	if after == before {
+		t.Fatalf("Score after a recorded execution should reflect the updated rolling stats, got the unchanged %v", after)
+	}
+}
+
+func TestExpertiseWeightedSelectorRespectsMaxCount(t *testing.T) {
+	candidates := []*AgentInfo{{ID: "a", Confidence: 0.5}, {ID: "b", Confidence: 0.5}, {ID: "c", Confidence: 0.5}}
+	sel := &expertiseWeightedSelector{}
+	req := SelectRequest{MaxCount: 2, Pattern: "some-pattern"}
+
+	subset, err := sel.Select(context.Background(), candidates, req)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	if len(subset) != 2 {
+		t.Fatalf("Select returned %d agents, want MaxCount %d", len(subset), req.MaxCount)
+	}
+
+	seen := make(map[string]bool)
+	for _, agent := range subset {
+		if seen[agent.ID] {
+			t.Fatalf("Select returned agent %q more than once: %v", agent.ID, subset)
+		}
+		seen[agent.ID] = true
+	}
+}
+
+func TestExpertiseWeightedSelectorScoreUsesPatternHistory(t *testing.T) {
+	stats := newSelectorStats()
+	sel := &expertiseWeightedSelector{stats: stats}
+	agent := &AgentInfo{ID: "a", Confidence: 0.5}
+	req := SelectRequest{Pattern: "summarize"}
+
+	if w := sel.Score(context.Background(), agent, req); w != 0.5 {
+		t.Fatalf("Score with no pattern history = %v, want the raw Confidence %v", w, agent.Confidence)
+	}
+
+	end := time.Now()
+	stats.Record(&PatternExecution{
+		Agents:     []string{"a"},
+		Pattern:    "summarize",
+		Duration:   time.Second,
+		Confidence: 0.1,
+		EndTime:    &end,
+	})
+
+	if w := sel.Score(context.Background(), agent, req); w != 0.1 {
+		t.Fatalf("Score after a recorded execution = %v, want the pattern-scoped rolling confidence %v", w, 0.1)
+	}
+
+	otherPattern := SelectRequest{Pattern: "classify"}
+	if w := sel.Score(context.Background(), agent, otherPattern); w != 0.5 {
+		t.Fatalf("Score(%q) = %v, want the raw Confidence %v since no history exists for that pattern", otherPattern.Pattern, w, agent.Confidence)
+	}
+}
+
+func TestSelectorStatsRecordIgnoresUnfinishedExecutions(t *testing.T) {
+	stats := newSelectorStats()
+	stats.Record(&PatternExecution{Agents: []string{"a"}, Confidence: 0.9, Duration: time.Second})
+
+	if _, ok := stats.Confidence("a"); ok {
+		t.Fatalf("Record should ignore an execution with a nil EndTime, but a sample was recorded")
+	}
+}
+
+func TestSelectorStatsRecordUpdatesRollingAverage(t *testing.T) {
+	stats := newSelectorStats()
+	end := time.Now()
+
+	stats.Record(&PatternExecution{Agents: []string{"a"}, Confidence: 1.0, Duration: time.Second, EndTime: &end})
+	first, ok := stats.Confidence("a")
+	if !ok || first != 1.0 {
+		t.Fatalf("Confidence after first sample = (%v, %v), want (1.0, true)", first, ok)
+	}
+
+	stats.Record(&PatternExecution{Agents: []string{"a"}, Confidence: 0.0, Duration: time.Second, EndTime: &end})
+	second, ok := stats.Confidence("a")
+	if !ok {
+		t.Fatalf("Confidence after second sample: not found")
+	}
+	if second >= first || second <= 0 {
+		t.Fatalf("Confidence after a lower sample = %v, want a value between 0 and %v (EMA, not a hard reset)", second, first)
+	}
+}
@@ -0,0 +1,159 @@
+package parallax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Service is a named unit of background work that runs for as long as the
+// context passed to Serve is alive. It replaces the old pattern of each
+// background loop owning its own `chan bool` stop signal: a Supervisor
+// drives Service.Serve with a shared context and restarts it with backoff
+// if it exits early, modeled after Syncthing's util.Service.
+type Service interface {
+	// Name identifies the service in logs and in Supervisor.Err.
+	Name() string
+
+	// Serve runs until ctx is canceled or an unrecoverable error occurs.
+	// Returning nil while ctx is still alive tells the Supervisor the
+	// service finished its work and should not be restarted; returning
+	// nil after ctx is canceled is the normal shutdown path.
+	Serve(ctx context.Context) error
+}
+
+// ServiceFunc adapts a plain function to the Service interface for
+// background loops that don't warrant their own type.
+type ServiceFunc struct {
+	ServiceName string
+	Func        func(ctx context.Context) error
+}
+
+// Name implements Service.
+func (f ServiceFunc) Name() string { return f.ServiceName }
+
+// Serve implements Service.
+func (f ServiceFunc) Serve(ctx context.Context) error { return f.Func(ctx) }
+
+// Supervisor runs a set of named Services under one parent context. A
+// service that returns an error while the context is still alive is
+// restarted with exponential backoff and jitter; its errors are collected
+// and available from Err once Serve returns.
+type Supervisor struct {
+	mu       sync.Mutex
+	services []Service
+	done     chan struct{}
+
+	errsMu sync.Mutex
+	errs   []error
+}
+
+// NewSupervisor creates an empty Supervisor. Register services with Add
+// before calling Serve.
+func NewSupervisor() *Supervisor {
+	return &Supervisor{}
+}
+
+// Add registers svc to be started the next time Serve is called.
+func (s *Supervisor) Add(svc Service) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.services = append(s.services, svc)
+}
+
+// Serve starts every registered service in its own goroutine and blocks
+// until ctx is canceled and all of them have returned.
+func (s *Supervisor) Serve(ctx context.Context) {
+	s.mu.Lock()
+	services := append([]Service(nil), s.services...)
+	done := make(chan struct{})
+	s.done = done
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, svc := range services {
+		wg.Add(1)
+		go func(svc Service) {
+			defer wg.Done()
+			s.run(ctx, svc)
+		}(svc)
+	}
+	wg.Wait()
+	close(done)
+}
+
+// Wait blocks until a Serve call started by this Supervisor has returned.
+// It returns immediately if Serve has never been called.
+func (s *Supervisor) Wait() {
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	if done == nil {
+		return
+	}
+	<-done
+}
+
+// Err returns the aggregated errors recorded by every restart since Serve
+// was called, or nil if the supervised services never failed.
+func (s *Supervisor) Err() error {
+	s.errsMu.Lock()
+	defer s.errsMu.Unlock()
+	if len(s.errs) == 0 {
+		return nil
+	}
+	return errors.Join(s.errs...)
+}
+
+// run drives svc until ctx is canceled, restarting it with backoff on
+// every non-nil error.
+func (s *Supervisor) run(ctx context.Context, svc Service) {
+	attempt := 0
+	for {
+		err := svc.Serve(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			// The service considers its work done and ctx is still alive;
+			// honor that rather than looping forever.
+			return
+		}
+
+		s.recordErr(fmt.Errorf("service %s: %w", svc.Name(), err))
+		log.Printf("service %s exited with error, restarting: %v", svc.Name(), err)
+
+		delay := backoffDelay(attempt)
+		attempt++
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *Supervisor) recordErr(err error) {
+	s.errsMu.Lock()
+	defer s.errsMu.Unlock()
+	s.errs = append(s.errs, err)
+}
+
+// backoffDelay returns an exponentially growing delay with jitter, capped
+// at two minutes, for the given zero-based restart attempt.
+func backoffDelay(attempt int) time.Duration {
+	const (
+		base = time.Second
+		max  = 2 * time.Minute
+	)
+
+	delay := base * time.Duration(1<<uint(attempt))
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
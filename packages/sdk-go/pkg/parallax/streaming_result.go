@@ -0,0 +1,28 @@
+package parallax
+
+// StreamingResultWriter wraps the emit callback GrpcAgent.StreamAnalyze
+// passes to a StreamEmitAnalyzer, giving SDK users Partial/Finalize
+// convenience methods instead of constructing an *AnalyzeResult by hand
+// for every call to emit.
+type StreamingResultWriter struct {
+	emit func(*AnalyzeResult) error
+}
+
+// NewStreamingResultWriter wraps emit.
+func NewStreamingResultWriter(emit func(*AnalyzeResult) error) *StreamingResultWriter {
+	return &StreamingResultWriter{emit: emit}
+}
+
+// Partial emits an intermediate result carrying just a value and
+// confidence, for analyzers that don't need Reasoning/Uncertainties/
+// Metadata on every chunk.
+func (w *StreamingResultWriter) Partial(value interface{}, confidence float64) error {
+	return w.emit(&AnalyzeResult{Value: value, Confidence: confidence})
+}
+
+// Finalize emits result as-is, for analyzers that want to fill in
+// Reasoning/Uncertainties/Metadata on their last chunk rather than using
+// Partial's bare value/confidence.
+func (w *StreamingResultWriter) Finalize(result *AnalyzeResult) error {
+	return w.emit(result)
+}
@@ -0,0 +1,248 @@
+package parallax
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
+)
+
+// SPIFFEConfig sources a client's identity (X.509 SVID) and trust bundle
+// from a SPIFFE Workload API, typically a local SPIRE agent, instead of
+// static cert/key/CA files. Setting it on TLSConfig takes precedence over
+// CertFile/KeyFile/CAFile/RootCAsPool.
+type SPIFFEConfig struct {
+	// WorkloadAPIAddr is the workload API socket address, e.g.
+	// "unix:///run/spire/sockets/agent.sock". Empty honors the
+	// SPIFFE_ENDPOINT_SOCKET environment variable, matching go-spiffe's
+	// own default.
+	WorkloadAPIAddr string
+}
+
+// TLSConfig configures transport security for a Client's connection to
+// the control plane.
+type TLSConfig struct {
+	CertFile   string
+	KeyFile    string
+	CAFile     string
+	ServerName string
+
+	// RootCAsPool, when set, seeds the trust pool instead of the system
+	// pool. CAFile, if also set, is appended to it.
+	RootCAsPool *x509.CertPool
+
+	// InsecureSkipVerify disables server certificate verification. For
+	// local development and tests only; never set this in production.
+	InsecureSkipVerify bool
+
+	// SPIFFE, when set, sources the client's identity and trust bundle
+	// from a SPIFFE Workload API instead of CertFile/KeyFile/CAFile.
+	SPIFFE *SPIFFEConfig
+}
+
+// tlsCredentials is the credentials.TransportCredentials NewClient hands
+// to the clientset, plus anything backing it (a cert watcher, a SPIFFE
+// workload API source) that needs closing on Client.Close.
+type tlsCredentials struct {
+	credentials.TransportCredentials
+	closers []func() error
+}
+
+func (c *tlsCredentials) Close() error {
+	var firstErr error
+	for _, closer := range c.closers {
+		if err := closer(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// buildTLSCredentials turns cfg into gRPC transport credentials. When
+// cfg.SPIFFE is set, identity and trust come from the SPIFFE Workload API;
+// otherwise CertFile/KeyFile/CAFile are loaded from disk, and if
+// CertFile/KeyFile are set, watched for changes so a rotated certificate
+// takes effect on the connection's next handshake without a restart.
+func buildTLSCredentials(cfg *TLSConfig, logger *zap.Logger) (*tlsCredentials, error) {
+	if cfg.SPIFFE != nil {
+		return buildSPIFFECredentials(cfg, logger)
+	}
+
+	tlsConf := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.RootCAsPool != nil {
+		tlsConf.RootCAs = cfg.RootCAsPool
+	}
+	if cfg.CAFile != "" {
+		pool := tlsConf.RootCAs
+		if pool == nil {
+			if sysPool, err := x509.SystemCertPool(); err == nil && sysPool != nil {
+				pool = sysPool
+			} else {
+				pool = x509.NewCertPool()
+			}
+		}
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConf.RootCAs = pool
+	}
+
+	creds := &tlsCredentials{}
+
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		watcher, err := newCertWatcher(cfg.CertFile, cfg.KeyFile, logger)
+		if err != nil {
+			return nil, err
+		}
+		tlsConf.GetClientCertificate = watcher.getClientCertificate
+		creds.closers = append(creds.closers, watcher.Close)
+	}
+
+	creds.TransportCredentials = credentials.NewTLS(tlsConf)
+	return creds, nil
+}
+
+// buildSPIFFECredentials configures mTLS against a SPIFFE Workload API,
+// authorizing any peer SVID from the trust bundle; callers needing
+// tighter authorization (a specific trust domain or SPIFFE ID) should use
+// CertFile/KeyFile/CAFile plus ServerName instead.
+func buildSPIFFECredentials(cfg *TLSConfig, logger *zap.Logger) (*tlsCredentials, error) {
+	var opts []workloadapi.X509SourceOption
+	if cfg.SPIFFE.WorkloadAPIAddr != "" {
+		opts = append(opts, workloadapi.WithClientOptions(workloadapi.WithAddr(cfg.SPIFFE.WorkloadAPIAddr)))
+	}
+
+	source, err := workloadapi.NewX509Source(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SPIFFE X.509 source: %w", err)
+	}
+
+	tlsConf := tlsconfig.MTLSClientConfig(source, source, tlsconfig.AuthorizeAny())
+	tlsConf.ServerName = cfg.ServerName
+
+	logger.Info("using SPIFFE workload API for client identity",
+		zap.String("workload_api_addr", cfg.SPIFFE.WorkloadAPIAddr))
+
+	return &tlsCredentials{
+		TransportCredentials: credentials.NewTLS(tlsConf),
+		closers:              []func() error{source.Close},
+	}, nil
+}
+
+// certWatcher holds the most recently loaded client certificate and
+// reloads it from disk whenever certFile or keyFile changes, so a rotated
+// certificate takes effect without tearing down existing connections.
+type certWatcher struct {
+	certFile string
+	keyFile  string
+	logger   *zap.Logger
+
+	cert    atomic.Pointer[tls.Certificate]
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+func newCertWatcher(certFile, keyFile string, logger *zap.Logger) (*certWatcher, error) {
+	cw := &certWatcher{certFile: certFile, keyFile: keyFile, logger: logger, done: make(chan struct{})}
+	if err := cw.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start certificate watcher: %w", err)
+	}
+
+	// Watch the containing directories rather than the files themselves:
+	// most tools rotate a certificate by writing a new file and renaming
+	// it over the old one, which fsnotify only reports as an event on the
+	// directory, not the (now-replaced) file.
+	for _, dir := range uniqueDirs(certFile, keyFile) {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	cw.watcher = watcher
+	go cw.run()
+	return cw, nil
+}
+
+func uniqueDirs(paths ...string) []string {
+	seen := make(map[string]struct{}, len(paths))
+	var dirs []string
+	for _, p := range paths {
+		dir := filepath.Dir(p)
+		if _, ok := seen[dir]; ok {
+			continue
+		}
+		seen[dir] = struct{}{}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+func (cw *certWatcher) reload() error {
+	cert, err := tls.LoadX509KeyPair(cw.certFile, cw.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	cw.cert.Store(&cert)
+	return nil
+}
+
+func (cw *certWatcher) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return cw.cert.Load(), nil
+}
+
+func (cw *certWatcher) run() {
+	for {
+		select {
+		case <-cw.done:
+			return
+		case event, ok := <-cw.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(cw.certFile) && filepath.Clean(event.Name) != filepath.Clean(cw.keyFile) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := cw.reload(); err != nil {
+				cw.logger.Warn("failed to reload client certificate", zap.Error(err))
+				continue
+			}
+			cw.logger.Info("reloaded client certificate", zap.String("cert_file", cw.certFile))
+		case err, ok := <-cw.watcher.Errors:
+			if !ok {
+				return
+			}
+			cw.logger.Warn("certificate watcher error", zap.Error(err))
+		}
+	}
+}
+
+func (cw *certWatcher) Close() error {
+	close(cw.done)
+	return cw.watcher.Close()
+}
@@ -0,0 +1,210 @@
+package parallax
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// tracerName identifies GrpcAgent's own spans in whatever backend
+// TracingConfig's OTLP exporter (or WithTracer's caller-supplied provider)
+// sends them to.
+const tracerName = "parallax/sdk-go"
+
+// requestIDMetadataKey is Parallax's own caller-supplied correlation id
+// (alongside the W3C traceparent/tracestate headers otelPropagator already
+// handles), recorded as a span attribute by startAnalyzeSpan.
+const requestIDMetadataKey = "parallax-request-id"
+
+var otelPropagator = propagation.TraceContext{}
+
+// metadataCarrier adapts grpc/metadata.MD to propagation.TextMapCarrier so
+// otelPropagator can Extract/Inject W3C trace context through gRPC
+// metadata instead of HTTP headers.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// extractIncomingTraceContext returns ctx with the span context carried by
+// traceparent/tracestate in ctx's incoming gRPC metadata, if any, so
+// g.tracer().Start continues the caller's trace instead of starting a new
+// one. A ctx with no incoming metadata (or no valid traceparent) is
+// returned unchanged, same as otelPropagator.Extract's own no-op case.
+func extractIncomingTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return otelPropagator.Extract(ctx, metadataCarrier(md))
+}
+
+// injectOutgoingTraceContext returns ctx with traceparent/tracestate for
+// ctx's current span attached as outgoing gRPC metadata, so an RPC issued
+// with the returned ctx (register/renew/unregister) shows up under the
+// same trace as whatever called it. A ctx with no recording span is a
+// no-op, same as otelPropagator.Inject's own behavior for an invalid span
+// context.
+func injectOutgoingTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otelPropagator.Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// SpanFromContext returns the OTel span GrpcAgent.Analyze started for the
+// current call, so an Agent's own Analyze implementation can add child
+// spans (e.g. around a slow external call) without importing
+// go.opentelemetry.io/otel/trace directly. Returns a non-recording span if
+// ctx carries none, e.g. in a unit test calling Agent.Analyze directly.
+func SpanFromContext(ctx context.Context) trace.Span {
+	return trace.SpanFromContext(ctx)
+}
+
+// startAnalyzeSpan extracts any inbound W3C trace context, starts a span
+// named "parallax.Analyze/<task>" as its child (or as a new trace root if
+// there was none), and records req's parallax-request-id header as a span
+// attribute if present.
+func (g *GrpcAgent) startAnalyzeSpan(ctx context.Context, task string) (context.Context, trace.Span) {
+	ctx = extractIncomingTraceContext(ctx)
+
+	ctx, span := g.tracer().Start(ctx, "parallax.Analyze/"+task)
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadataKey); len(values) > 0 {
+			span.SetAttributes(attribute.String("parallax.request_id", values[0]))
+		}
+	}
+	return ctx, span
+}
+
+// recordAnalyzeResult attaches result's confidence, reasoning, and
+// uncertainties to span once Agent.Analyze has returned successfully.
+func recordAnalyzeResult(span trace.Span, result *AnalyzeResult) {
+	span.SetAttributes(
+		attribute.Float64("parallax.confidence", result.Confidence),
+		attribute.String("parallax.reasoning", result.Reasoning),
+		attribute.StringSlice("parallax.uncertainties", result.Uncertainties),
+	)
+}
+
+// tracer returns the trace.Tracer GrpcAgent.Analyze starts spans with,
+// building it on first use: WithTracer's provider if one was passed,
+// otherwise an OTLP/gRPC exporter configured from TracingConfig (env
+// PARALLAX_TRACING_ENDPOINT/PARALLAX_TRACING_SAMPLE_RATE, or a push from
+// the control plane's ConfigStream received before this call), or
+// otel's default no-op tracer if neither names an endpoint. Tracing is
+// therefore opt-in: without an exporter configured, spans are created but
+// never leave the process.
+func (g *GrpcAgent) tracer() trace.Tracer {
+	g.tracerOnce.Do(func() {
+		if g.opts.tracer != nil {
+			g.tracerImpl = g.opts.tracer.Tracer(tracerName)
+			return
+		}
+
+		cfg := g.config.tracingConfig()
+		if cfg.Endpoint == "" {
+			g.tracerImpl = otel.Tracer(tracerName)
+			return
+		}
+
+		tp, err := newOTLPTracerProvider(cfg)
+		if err != nil {
+			log.Printf("failed to configure OTLP tracing, continuing without exported spans: %v", err)
+			g.tracerImpl = otel.Tracer(tracerName)
+			return
+		}
+
+		g.tracerShutdown = tp.Shutdown
+		g.tracerImpl = tp.Tracer(tracerName)
+	})
+	return g.tracerImpl
+}
+
+// newOTLPTracerProvider builds an sdktrace.TracerProvider exporting to
+// cfg.Endpoint over OTLP/gRPC, sampling at cfg.SampleRate (a ratio in
+// [0,1]; values <= 0 fall back to always-on).
+func newOTLPTracerProvider(cfg TracingConfig) (*sdktrace.TracerProvider, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(cfg.Endpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter for %s: %w", cfg.Endpoint, err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRate > 0 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRate)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+	), nil
+}
+
+// tracingConfig returns the currently applied TracingConfig, env defaults
+// seeded by seedTracingFromEnv until a ConfigStream push (see
+// config_stream.go) replaces it.
+func (s *configState) tracingConfig() TracingConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.tracing
+}
+
+// seedTracingFromEnv sets cfg.tracing from PARALLAX_TRACING_ENDPOINT/
+// PARALLAX_TRACING_SAMPLE_RATE when PARALLAX_TRACING_ENDPOINT is set,
+// mirroring grpcTLSConfigFromEnv's env-seeding convention. Leaves tracing
+// unset (the zero value, i.e. no exporter) when the endpoint isn't
+// configured.
+func (s *configState) seedTracingFromEnv() {
+	endpoint := os.Getenv("PARALLAX_TRACING_ENDPOINT")
+	if endpoint == "" {
+		return
+	}
+
+	sampleRate := 1.0
+	if raw := os.Getenv("PARALLAX_TRACING_SAMPLE_RATE"); raw != "" {
+		if v, err := strconv.ParseFloat(raw, 64); err == nil {
+			sampleRate = v
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg.tracing = TracingConfig{Endpoint: endpoint, SampleRate: sampleRate}
+}
@@ -15,6 +15,20 @@ type AgentInfo struct {
 	LastSeen     time.Time         `json:"lastSeen"`
 	Confidence   float64           `json:"confidence"`
 	Metadata     map[string]string `json:"metadata,omitempty"`
+
+	// Policy, when set, is this agent's default ConfidencePolicy.
+	// agentService.Register serializes it into the AgentRegistration's
+	// Labels under PolicyMetadataKey, and agentFromRegistration parses it
+	// back out on List/Get/StreamAgents.
+	Policy ConfidencePolicy `json:"policy,omitempty"`
+
+	// PolicyEvaluation reports the most recently recorded ConfidencePolicy
+	// match for this agent. agentService.StreamAgents attaches it from the
+	// Client's PolicyEvaluationTracker (if configured) alongside each
+	// update, so a control plane can observe which scopes are currently
+	// blocking or warning. Nil when no tracker is configured or no
+	// evaluation has been recorded yet.
+	PolicyEvaluation *PolicyEvaluationSummary `json:"policyEvaluation,omitempty"`
 }
 
 // AgentStatus represents the status of an agent
@@ -28,37 +42,43 @@ const (
 
 // Pattern represents a coordination pattern
 type Pattern struct {
-	Name                 string   `json:"name"`
-	Description          string   `json:"description"`
-	Enabled              bool     `json:"enabled"`
-	RequiredCapabilities []string `json:"requiredCapabilities"`
+	Name                 string        `json:"name"`
+	Description          string        `json:"description"`
+	Enabled              bool          `json:"enabled"`
+	RequiredCapabilities []string      `json:"requiredCapabilities"`
 	Config               PatternConfig `json:"config"`
 }
 
 // PatternConfig holds pattern-specific configuration
 type PatternConfig struct {
-	MinAgents           int               `json:"minAgents,omitempty"`
-	MaxAgents           int               `json:"maxAgents,omitempty"`
-	ConsensusThreshold  float64           `json:"consensusThreshold,omitempty"`
-	ConfidenceThreshold float64           `json:"confidenceThreshold,omitempty"`
-	Timeout             time.Duration     `json:"timeout,omitempty"`
+	MinAgents           int                    `json:"minAgents,omitempty"`
+	MaxAgents           int                    `json:"maxAgents,omitempty"`
+	ConsensusThreshold  float64                `json:"consensusThreshold,omitempty"`
+	ConfidenceThreshold float64                `json:"confidenceThreshold,omitempty"`
+	Timeout             time.Duration          `json:"timeout,omitempty"`
 	Parameters          map[string]interface{} `json:"parameters,omitempty"`
 }
 
 // PatternExecution represents the execution of a pattern
 type PatternExecution struct {
-	ID         string                 `json:"id"`
-	Pattern    string                 `json:"pattern"`
-	Status     ExecutionStatus        `json:"status"`
-	Input      interface{}            `json:"input"`
-	Output     interface{}            `json:"output,omitempty"`
-	Agents     []string               `json:"agents"`
-	StartTime  time.Time              `json:"startTime"`
-	EndTime    *time.Time             `json:"endTime,omitempty"`
-	Duration   time.Duration          `json:"duration,omitempty"`
-	Confidence float64                `json:"confidence,omitempty"`
-	Error      string                 `json:"error,omitempty"`
-	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	ID         string          `json:"id"`
+	Pattern    string          `json:"pattern"`
+	Status     ExecutionStatus `json:"status"`
+	Input      interface{}     `json:"input"`
+	Output     interface{}     `json:"output,omitempty"`
+	Agents     []string        `json:"agents"`
+	StartTime  time.Time       `json:"startTime"`
+	EndTime    *time.Time      `json:"endTime,omitempty"`
+	Duration   time.Duration   `json:"duration,omitempty"`
+	Confidence float64         `json:"confidence,omitempty"`
+	Error      string          `json:"error,omitempty"`
+
+	// Metadata carries opts.Metadata plus, whenever AgentSelector.Strategy
+	// narrowed the eligible fleet down, the "selector_strategy" name and
+	// "selector_scores" patternService.eligibleAgents recorded for it, so
+	// which agents were picked (and why) is visible without re-deriving
+	// it from logs.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // ExecutionStatus represents the status of pattern execution
@@ -73,12 +93,17 @@ const (
 
 // ExecuteOptions holds options for pattern execution
 type ExecuteOptions struct {
-	Async          bool                   `json:"async,omitempty"`
-	Priority       int                    `json:"priority,omitempty"`
-	Timeout        time.Duration          `json:"timeout,omitempty"`
-	AgentSelector  AgentSelector          `json:"agentSelector,omitempty"`
-	Metadata       map[string]interface{} `json:"metadata,omitempty"`
-	TraceID        string                 `json:"traceId,omitempty"`
+	Async         bool                   `json:"async,omitempty"`
+	Priority      int                    `json:"priority,omitempty"`
+	Timeout       time.Duration          `json:"timeout,omitempty"`
+	AgentSelector AgentSelector          `json:"agentSelector,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	TraceID       string                 `json:"traceId,omitempty"`
+
+	// Reducer combines the per-shard AgentResults produced by a map-reduce
+	// style executor into the execution's final Output. When nil, the
+	// built-in map-reduce executor collects raw shard values into a slice.
+	Reducer func(results []*AgentResult) (interface{}, error) `json:"-"`
 }
 
 // AgentSelector defines how to select agents for pattern execution
@@ -88,16 +113,32 @@ type AgentSelector struct {
 	MinCount     int               `json:"minCount,omitempty"`
 	MaxCount     int               `json:"maxCount,omitempty"`
 	Strategy     SelectionStrategy `json:"strategy,omitempty"`
+
+	// Scorer overrides the AgentScorer the "best_fit" Selector (and any
+	// custom Selector that chooses to honor it) ranks candidates with.
+	// Nil falls back to scoring by AgentInfo.Confidence.
+	Scorer AgentScorer `json:"-"`
 }
 
-// SelectionStrategy defines how agents are selected
+// SelectionStrategy names a Selector registered via RegisterSelector that
+// narrows eligible agents down to AgentSelector.MaxCount candidates. It's
+// a free-form string, not a closed set: the constants below name the
+// built-ins, but any name RegisterSelector has been called with (e.g.
+// "consistent-hash-by-input", or a third party's own) works equally well.
 type SelectionStrategy string
 
 const (
-	SelectionStrategyRandom     SelectionStrategy = "random"
+	// SelectionStrategyRandom picks a uniformly random subset.
+	SelectionStrategyRandom SelectionStrategy = "random"
+	// SelectionStrategyRoundRobin spreads load across the eligible fleet
+	// by advancing a cursor on every call instead of always preferring
+	// the same candidates.
 	SelectionStrategyRoundRobin SelectionStrategy = "round_robin"
-	SelectionStrategyBestFit    SelectionStrategy = "best_fit"
-	SelectionStrategyAll        SelectionStrategy = "all"
+	// SelectionStrategyBestFit ranks candidates by AgentSelector.Scorer
+	// (or Confidence, if unset) and takes the top MaxCount.
+	SelectionStrategyBestFit SelectionStrategy = "best_fit"
+	// SelectionStrategyAll opts out of MaxCount truncation entirely.
+	SelectionStrategyAll SelectionStrategy = "all"
 )
 
 // PatternService defines operations on patterns
@@ -119,6 +160,138 @@ type PatternService interface {
 
 	// StreamExecutions streams pattern execution updates
 	StreamExecutions(ctx context.Context) (<-chan *PatternExecution, error)
+
+	// StreamAnalyze fans out a StreamAnalyze call to every agent in agents
+	// and multiplexes their incremental AgentResult updates onto a single
+	// channel, closing it once every participating agent's stream ends or
+	// ctx is cancelled.
+	StreamAnalyze(ctx context.Context, agents []*AgentInfo, task string, data interface{}) (<-chan *AgentResult, error)
+
+	// Register installs a PatternExecutor under name, making it available
+	// to Execute/Stream and listed by List/Get. It overrides any existing
+	// executor registered under the same name, including the built-in
+	// consensus-builder and map-reduce patterns, so third parties can add
+	// or replace orchestration patterns without forking the SDK.
+	Register(name string, executor PatternExecutor)
+
+	// SubmitJob runs spec as a batch workload: it waits for spec.DependsOn
+	// to succeed, then drives spec.Pattern through Execute with retries
+	// bounded by spec.BackoffLimit and a deadline bounded by
+	// spec.ActiveDeadlineSeconds, without requiring the caller to keep any
+	// agent resident for the duration. It returns as soon as the job
+	// reaches a terminal phase; use StreamJob for incremental updates.
+	// This is the SDK-side half of the ParallaxJob CRD: the operator
+	// materializes the batchv1.Job that actually runs SubmitJob
+	// inside a pod, while this method is what that pod's process calls.
+	SubmitJob(ctx context.Context, spec *JobSpec) (*JobExecution, error)
+
+	// StreamJob streams phase transitions for the job with the given ID as
+	// SubmitJob (or a concurrent StreamJob caller) observes them, closing
+	// the channel once the job reaches a terminal phase or ctx is
+	// cancelled. Returns an error if no job with that ID has been
+	// submitted through this PatternService.
+	StreamJob(ctx context.Context, id string) (<-chan *JobEvent, error)
+}
+
+// JobType names the kind of batch workload a ParallaxJob runs, mirroring
+// the multi-phase index-job pattern (rotate/creation/save/correction)
+// used by vector-DB operators.
+type JobType string
+
+const (
+	JobTypeAnalyze    JobType = "Analyze"
+	JobTypeRotate     JobType = "Rotate"
+	JobTypeCorrection JobType = "Correction"
+	JobTypeSave       JobType = "Save"
+)
+
+// JobSpec describes one batch/long-running pattern execution, the SDK
+// counterpart to a ParallaxJobSpec the operator's JobReconciler
+// materializes into a batchv1.Job.
+type JobSpec struct {
+	// Type categorizes this job for observability and for DependsOn
+	// chaining; it has no effect on which Pattern is executed.
+	Type JobType `json:"type"`
+
+	// Pattern is the registered PatternExecutor this job drives via
+	// Execute, e.g. "consensus-builder" or "map-reduce".
+	Pattern string `json:"pattern"`
+
+	// Input is passed to Execute unchanged.
+	Input interface{} `json:"input"`
+
+	// Options configures agent selection, timeout, and metadata for the
+	// underlying Execute call.
+	Options *ExecuteOptions `json:"options,omitempty"`
+
+	// DependsOn lists job IDs that must reach JobSucceeded before this
+	// job starts, e.g. a Correction job naming a prior Analyze job. A job
+	// referenced here that instead reaches JobFailed fails this job
+	// without starting it.
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// BackoffLimit caps retries on Execute failure after the first
+	// attempt, so Execute runs up to BackoffLimit+1 times. Zero means a
+	// single attempt.
+	BackoffLimit int `json:"backoffLimit,omitempty"`
+
+	// ActiveDeadlineSeconds bounds the job's total runtime, including any
+	// time spent waiting on DependsOn. Zero means no deadline.
+	ActiveDeadlineSeconds int64 `json:"activeDeadlineSeconds,omitempty"`
+}
+
+// JobPhase represents the lifecycle phase of a JobExecution, matching
+// ParallaxJobStatus.Phase's values in the operator's CRD.
+type JobPhase string
+
+const (
+	JobPending   JobPhase = "Pending"
+	JobRunning   JobPhase = "Running"
+	JobSucceeded JobPhase = "Succeeded"
+	JobFailed    JobPhase = "Failed"
+)
+
+// JobExecution represents the observed state of one SubmitJob call.
+type JobExecution struct {
+	ID        string            `json:"id"`
+	Spec      *JobSpec          `json:"spec"`
+	Phase     JobPhase          `json:"phase"`
+	Execution *PatternExecution `json:"execution,omitempty"`
+	Attempts  int               `json:"attempts"`
+	StartTime time.Time         `json:"startTime"`
+	EndTime   *time.Time        `json:"endTime,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// JobEvent is a single incremental update from StreamJob. Job is always
+// set; Err is set alongside it on the terminal event of a failed job and
+// holds the error whose text is in Job.Error. A cancelled ctx sends no
+// event; it closes the channel.
+type JobEvent struct {
+	Job *JobExecution
+	Err error
+}
+
+// PatternExecutor implements the orchestration logic behind a single
+// pattern name. The built-in consensus-builder and map-reduce patterns are
+// themselves PatternExecutors; PatternService.Register lets callers plug in
+// their own.
+type PatternExecutor interface {
+	// Execute runs the pattern against input using agents and returns once
+	// it completes.
+	Execute(ctx context.Context, input interface{}, agents []*AgentInfo, opts *ExecuteOptions) (*PatternExecution, error)
+
+	// Stream runs the pattern and reports PatternEvents as they occur,
+	// closing the channel once the pattern finishes or ctx is cancelled.
+	Stream(ctx context.Context, input interface{}, agents []*AgentInfo, opts *ExecuteOptions) <-chan PatternEvent
+}
+
+// PatternEvent is a single incremental update from a streaming pattern
+// execution. Err is set instead of Execution when the pattern fails to
+// produce an update.
+type PatternEvent struct {
+	Execution *PatternExecution
+	Err       error
 }
 
 // AgentService defines operations on agents
@@ -146,4 +319,4 @@ type AgentService interface {
 
 	// StreamAgents streams agent updates
 	StreamAgents(ctx context.Context) (<-chan *AgentInfo, error)
-}
\ No newline at end of file
+}
@@ -28,4 +28,33 @@ func mustMarshalJSON(v interface{}) string {
 		panic(err)
 	}
 	return string(data)
+}
+
+// hasCapabilities reports whether have contains every capability in want.
+func hasCapabilities(have []string, want []string) bool {
+	if len(want) == 0 {
+		return true
+	}
+
+	set := make(map[string]struct{}, len(have))
+	for _, c := range have {
+		set[c] = struct{}{}
+	}
+
+	for _, c := range want {
+		if _, ok := set[c]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
\ No newline at end of file